@@ -7,10 +7,13 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/Hymiside/fitness-api/pkg/handler"
 	"github.com/Hymiside/fitness-api/pkg/repository"
+	"github.com/Hymiside/fitness-api/pkg/sender"
 	"github.com/Hymiside/fitness-api/pkg/service"
 	"github.com/gocraft/dbr/v2"
 	_ "github.com/lib/pq"
@@ -43,9 +46,43 @@ func main() {
 		log.Fatalf("connection test error: %v", err)
 	}
 
+	signingKeys, err := parseSigningKeys(os.Getenv("JWT_SIGNING_KEYS"))
+	if err != nil {
+		log.Fatalf("invalid JWT_SIGNING_KEYS: %v", err)
+	}
+	activeKeyID := os.Getenv("JWT_ACTIVE_KEY_ID")
+	if _, ok := signingKeys[activeKeyID]; !ok {
+		log.Fatalf("JWT_ACTIVE_KEY_ID %q is not in JWT_SIGNING_KEYS", activeKeyID)
+	}
+	tokenTTL, err := time.ParseDuration(os.Getenv("JWT_TOKEN_TTL"))
+	if err != nil {
+		log.Fatalf("invalid JWT_TOKEN_TTL: %v", err)
+	}
+	refreshTokenTTL, err := time.ParseDuration(os.Getenv("JWT_REFRESH_TOKEN_TTL"))
+	if err != nil {
+		log.Fatalf("invalid JWT_REFRESH_TOKEN_TTL: %v", err)
+	}
+
+	codeSender, err := newSender(os.Getenv("SENDER"), os.Getenv("APP_ENV"))
+	if err != nil {
+		log.Fatalf("invalid SENDER: %v", err)
+	}
+	if codeSender == nil {
+		log.Printf("SENDER is not set, clients cannot request sign-in codes")
+	}
+
 	repos := repository.NewRepository(db)
-	services := service.NewService(repos)
-	handlers := handler.NewHandler(services)
+	services := service.NewService(repos, service.Config{
+		SigningKeys:     signingKeys,
+		ActiveKeyID:     activeKeyID,
+		TokenTTL:        tokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+	}, codeSender)
+	handlers := handler.NewHandler(services, parseList(os.Getenv("TRUSTED_PROXIES")))
+	router, err := handlers.InitRoutes()
+	if err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
 
 
 	go func() {
@@ -62,7 +99,7 @@ func main() {
 
 	httpServer := &http.Server{
 		Addr:    fmt.Sprintf("%s:%s", os.Getenv("SERVER_HOST"), os.Getenv("SERVER_PORT")),
-		Handler: handlers.InitRoutes(),
+		Handler: router,
 	}
 
 	go func() {
@@ -79,4 +116,45 @@ func main() {
 		}
 		log.Fatalf("failed to start server: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+// parseSigningKeys reads keys in the form "kid1:secret1,kid2:secret2".
+func parseSigningKeys(raw string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for _, pair := range strings.Split(raw, ",") {
+		kid, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || kid == "" || secret == "" {
+			return nil, fmt.Errorf("malformed key %q", pair)
+		}
+		keys[kid] = []byte(secret)
+	}
+	return keys, nil
+}
+
+// parseList reads a comma separated list, such as "10.0.0.1,10.0.1.0/24".
+func parseList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// newSender picks how sign-in codes are delivered. Without a sender the API
+// still runs, only clients cannot request codes. The log sender prints codes
+// in plain text, so it is only allowed with APP_ENV=development.
+func newSender(kind, env string) (sender.Sender, error) {
+	switch kind {
+	case "":
+		return nil, nil
+	case "log":
+		if env != "development" {
+			return nil, fmt.Errorf("the log sender is only allowed with APP_ENV=development")
+		}
+		return sender.NewLogSender(), nil
+	default:
+		return nil, fmt.Errorf("unknown sender %q", kind)
+	}
+}
@@ -0,0 +1,11 @@
+create table sessions (
+    id serial primary key,
+    user_id integer not null,
+    role varchar not null, -- 'sudo' or 'admin' or 'trainer'
+    refresh_token_hash varchar not null unique,
+    expires_at timestamp not null,
+    revoked_at timestamp,
+    created_at timestamp not null DEFAULT NOW()
+);
+
+create index sessions_user_id_idx on sessions (user_id, role);
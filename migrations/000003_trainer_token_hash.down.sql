@@ -0,0 +1,7 @@
+alter table trainers drop column token_expires_at;
+
+-- raw tokens cannot be recovered, trainers need new ones after rollback
+alter table trainers add column token varchar;
+update trainers set token = token_hash;
+alter table trainers alter column token set not null;
+alter table trainers drop column token_hash;
@@ -0,0 +1,7 @@
+alter table trainers add column token_hash varchar;
+update trainers set token_hash = encode(sha256(token::bytea), 'hex');
+alter table trainers alter column token_hash set not null;
+alter table trainers add constraint trainers_token_hash_key unique (token_hash);
+alter table trainers drop column token;
+
+alter table trainers add column token_expires_at timestamp;
@@ -0,0 +1 @@
+drop table login_attempts;
\ No newline at end of file
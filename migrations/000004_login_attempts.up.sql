@@ -0,0 +1,6 @@
+create table login_attempts (
+    key varchar primary key, -- 'admin:<login>' or 'ip:<address>'
+    failures integer not null default 0,
+    locked_until timestamp,
+    last_failure_at timestamp not null DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+drop table admin_recovery_codes;
+
+alter table admins drop column totp_enabled;
+alter table admins drop column totp_secret;
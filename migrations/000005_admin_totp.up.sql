@@ -0,0 +1,11 @@
+alter table admins add column totp_secret varchar;
+alter table admins add column totp_enabled boolean not null DEFAULT false;
+
+create table admin_recovery_codes (
+    id serial primary key,
+    admin_id integer not null,
+    code_hash varchar not null,
+    used_at timestamp,
+
+    foreign key (admin_id) references admins(id) ON DELETE CASCADE
+);
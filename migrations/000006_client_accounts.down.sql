@@ -0,0 +1,4 @@
+drop table client_login_codes;
+
+alter table clients drop column email;
+alter table clients drop column phone_number;
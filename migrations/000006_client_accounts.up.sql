@@ -0,0 +1,13 @@
+alter table clients add column phone_number varchar unique;
+alter table clients add column email varchar unique;
+
+create table client_login_codes (
+    id serial primary key,
+    client_id integer not null,
+    code_hash varchar not null,
+    expires_at timestamp not null,
+    used_at timestamp,
+    created_at timestamp not null DEFAULT NOW(),
+
+    foreign key (client_id) references clients(id) ON DELETE CASCADE
+);
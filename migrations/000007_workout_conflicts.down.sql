@@ -0,0 +1,5 @@
+alter table workouts drop constraint workouts_client_no_overlap;
+alter table workouts drop constraint workouts_trainer_no_overlap;
+alter table workouts drop column ends_at;
+
+alter table workout_types drop column duration;
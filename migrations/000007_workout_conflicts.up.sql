@@ -0,0 +1,31 @@
+create extension if not exists btree_gist;
+
+alter table workout_types add column duration integer not null DEFAULT 60; -- minutes
+
+alter table workouts add column ends_at timestamp;
+update workouts set ends_at = workouts.date + make_interval(mins => workout_types.duration)
+    from workout_types where workout_types.id = workouts.workout_type_id;
+alter table workouts alter column ends_at set not null;
+
+-- Workouts used to be stamped with NOW(), so ones created less than an hour
+-- apart overlap with the default duration. Cut every such workout short at
+-- the start of the next workout of the same trainer or client, otherwise
+-- the constraints below cannot be added.
+update workouts set ends_at = next.date
+    from (
+        select w.id, min(o.date) as date
+        from workouts w
+        join workouts o on o.id <> w.id
+            and (o.trainer_id = w.trainer_id or o.client_id = w.client_id)
+            and (o.date > w.date or (o.date = w.date and o.id > w.id))
+            and o.date < w.ends_at
+            and o.status <> 'canceled'
+        where w.status <> 'canceled'
+        group by w.id
+    ) next
+    where workouts.id = next.id;
+
+alter table workouts add constraint workouts_trainer_no_overlap
+    exclude using gist (trainer_id with =, tsrange(date, ends_at) with &&) where (status <> 'canceled');
+alter table workouts add constraint workouts_client_no_overlap
+    exclude using gist (client_id with =, tsrange(date, ends_at) with &&) where (status <> 'canceled');
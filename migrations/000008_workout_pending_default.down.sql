@@ -0,0 +1 @@
+alter table workouts alter column status set DEFAULT 'done';
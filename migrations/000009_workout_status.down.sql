@@ -0,0 +1,4 @@
+drop table workout_status_changes;
+
+alter table workouts drop column status_changed_at;
+alter table workouts drop constraint workouts_status_check;
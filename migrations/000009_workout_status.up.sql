@@ -0,0 +1,16 @@
+-- rows written before the check existed are left as they are
+alter table workouts add constraint workouts_status_check
+    check (status in ('pending', 'done', 'canceled', 'no_show')) not valid;
+alter table workouts add column status_changed_at timestamp;
+
+create table workout_status_changes (
+    id serial primary key,
+    workout_id integer not null,
+    from_status varchar not null,
+    to_status varchar not null,
+    actor_id integer not null,
+    actor_role varchar not null,
+    changed_at timestamp not null DEFAULT NOW(),
+
+    foreign key (workout_id) references workouts(id) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+alter table workouts drop constraint workouts_client_no_overlap;
+alter table workouts drop constraint workouts_trainer_no_overlap;
+alter table workouts add constraint workouts_trainer_no_overlap
+    exclude using gist (trainer_id with =, tsrange(date, ends_at) with &&) where (status <> 'canceled');
+alter table workouts add constraint workouts_client_no_overlap
+    exclude using gist (client_id with =, tsrange(date, ends_at) with &&) where (status <> 'canceled');
+
+alter table workouts drop column series_id;
+
+drop table workout_series;
@@ -0,0 +1,32 @@
+create table workout_series (
+    id serial primary key,
+    client_id integer not null,
+    trainer_id integer not null,
+    admin_id integer not null,
+    workout_type_id integer not null,
+
+    starts_at timestamp not null,
+    interval_weeks integer not null, -- 1 is weekly, 2 is biweekly
+    until timestamp,
+    count integer,
+    created_at timestamp not null DEFAULT NOW(),
+
+    check (until is not null or count is not null),
+    foreign key (client_id) references clients(id) ON DELETE CASCADE,
+    foreign key (trainer_id) references trainers(id) ON DELETE CASCADE,
+    foreign key (admin_id) references admins(id) ON DELETE CASCADE,
+    foreign key (workout_type_id) references workout_types(id) ON DELETE CASCADE
+);
+
+alter table workouts add column series_id integer references workout_series(id) ON DELETE SET NULL;
+
+-- Moving several occurrences of a series may overlap them with each other
+-- until the last one is moved, so overlaps can be checked at commit.
+alter table workouts drop constraint workouts_trainer_no_overlap;
+alter table workouts drop constraint workouts_client_no_overlap;
+alter table workouts add constraint workouts_trainer_no_overlap
+    exclude using gist (trainer_id with =, tsrange(date, ends_at) with &&) where (status <> 'canceled')
+    deferrable initially immediate;
+alter table workouts add constraint workouts_client_no_overlap
+    exclude using gist (client_id with =, tsrange(date, ends_at) with &&) where (status <> 'canceled')
+    deferrable initially immediate;
@@ -0,0 +1,3 @@
+drop table trainer_availability_exceptions;
+
+drop table trainer_working_hours;
@@ -0,0 +1,26 @@
+create table trainer_working_hours (
+    id serial primary key,
+    trainer_id integer not null,
+    weekday integer not null check (weekday between 0 and 6), -- 0 is Sunday
+    starts_at time not null,
+    ends_at time not null,
+
+    check (starts_at < ends_at),
+    foreign key (trainer_id) references trainers(id) ON DELETE CASCADE
+);
+
+-- An exception replaces the weekly hours for its date. An exception
+-- without hours is a day off.
+create table trainer_availability_exceptions (
+    id serial primary key,
+    trainer_id integer not null,
+    date date not null,
+    starts_at time,
+    ends_at time,
+    reason varchar(255) not null DEFAULT '',
+
+    check ((starts_at is null and ends_at is null) or starts_at < ends_at),
+    foreign key (trainer_id) references trainers(id) ON DELETE CASCADE
+);
+
+create index trainer_availability_exceptions_trainer_date on trainer_availability_exceptions (trainer_id, date);
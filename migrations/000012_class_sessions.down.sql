@@ -0,0 +1,3 @@
+drop table class_attendees;
+
+drop table class_sessions;
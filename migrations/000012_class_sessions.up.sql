@@ -0,0 +1,33 @@
+create table class_sessions (
+    id serial primary key,
+    trainer_id integer not null,
+    workout_type_id integer not null,
+    admin_id integer not null,
+
+    capacity integer not null check (capacity > 0),
+    date timestamp not null,
+    ends_at timestamp not null,
+    status varchar(20) not null DEFAULT 'pending' check (status in ('pending', 'done', 'canceled', 'no_show')),
+    status_changed_at timestamp,
+    created_at timestamp not null DEFAULT NOW(),
+
+    foreign key (trainer_id) references trainers(id) ON DELETE CASCADE,
+    foreign key (workout_type_id) references workout_types(id) ON DELETE CASCADE,
+    foreign key (admin_id) references admins(id) ON DELETE CASCADE,
+    constraint class_sessions_trainer_no_overlap
+        exclude using gist (trainer_id with =, tsrange(date, ends_at) with &&) where (status <> 'canceled')
+);
+
+create table class_attendees (
+    id serial primary key,
+    class_session_id integer not null,
+    client_id integer not null,
+
+    status varchar(20) not null DEFAULT 'pending' check (status in ('pending', 'done', 'canceled', 'no_show')),
+    booked_at timestamp not null DEFAULT NOW(),
+    status_changed_at timestamp,
+
+    unique (class_session_id, client_id),
+    foreign key (class_session_id) references class_sessions(id) ON DELETE CASCADE,
+    foreign key (client_id) references clients(id) ON DELETE CASCADE
+);
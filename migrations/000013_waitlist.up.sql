@@ -0,0 +1,31 @@
+-- A client waits either for a place in a group class or for a trainer slot
+-- taken by another workout.
+create table waitlist_entries (
+    id serial primary key,
+    client_id integer not null,
+    admin_id integer not null,
+
+    class_session_id integer,
+    trainer_id integer,
+    workout_type_id integer,
+    date timestamp,
+
+    status varchar(20) not null DEFAULT 'waiting' check (status in ('waiting', 'promoted', 'left')),
+    created_at timestamp not null DEFAULT NOW(),
+    status_changed_at timestamp,
+
+    check (
+        (class_session_id is not null and trainer_id is null and workout_type_id is null and date is null) or
+        (class_session_id is null and trainer_id is not null and workout_type_id is not null and date is not null)
+    ),
+    foreign key (client_id) references clients(id) ON DELETE CASCADE,
+    foreign key (admin_id) references admins(id) ON DELETE CASCADE,
+    foreign key (class_session_id) references class_sessions(id) ON DELETE CASCADE,
+    foreign key (trainer_id) references trainers(id) ON DELETE CASCADE,
+    foreign key (workout_type_id) references workout_types(id) ON DELETE CASCADE
+);
+
+create unique index waitlist_entries_class_client on waitlist_entries (class_session_id, client_id)
+    where status = 'waiting' and class_session_id is not null;
+create unique index waitlist_entries_slot_client on waitlist_entries (trainer_id, date, client_id)
+    where status = 'waiting' and trainer_id is not null;
@@ -0,0 +1,8 @@
+alter table class_sessions drop constraint class_sessions_resource_no_overlap;
+alter table workouts drop constraint workouts_resource_no_overlap;
+
+alter table class_sessions drop column resource_id;
+alter table workouts drop column resource_id;
+alter table workout_types drop column resource_id;
+
+drop table resources;
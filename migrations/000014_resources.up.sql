@@ -0,0 +1,16 @@
+create table resources (
+    id serial primary key,
+    title varchar(255) not null,
+    kind varchar(50) not null DEFAULT 'room', -- room or equipment
+    capacity integer not null DEFAULT 1 check (capacity > 0) -- people at once
+);
+
+alter table workout_types add column resource_id integer references resources(id) ON DELETE SET NULL;
+alter table workouts add column resource_id integer references resources(id) ON DELETE SET NULL;
+alter table class_sessions add column resource_id integer references resources(id) ON DELETE SET NULL;
+
+alter table workouts add constraint workouts_resource_no_overlap
+    exclude using gist (resource_id with =, tsrange(date, ends_at) with &&) where (status <> 'canceled' and resource_id is not null)
+    deferrable initially immediate;
+alter table class_sessions add constraint class_sessions_resource_no_overlap
+    exclude using gist (resource_id with =, tsrange(date, ends_at) with &&) where (status <> 'canceled' and resource_id is not null);
@@ -0,0 +1,3 @@
+drop table workout_charges;
+
+drop table cancellation_policy;
@@ -0,0 +1,22 @@
+-- The policy is a single row.
+create table cancellation_policy (
+    id integer primary key DEFAULT 1 check (id = 1),
+    free_cancel_minutes integer not null DEFAULT 1440 check (free_cancel_minutes >= 0),
+    late_cancel_fee_percent integer not null DEFAULT 50 check (late_cancel_fee_percent between 0 and 100),
+    no_show_fee_percent integer not null DEFAULT 100 check (no_show_fee_percent between 0 and 100),
+    trainer_share_percent integer not null DEFAULT 50 check (trainer_share_percent between 0 and 100), -- of the fee
+    updated_at timestamp not null DEFAULT NOW()
+);
+
+insert into cancellation_policy (id) values (1);
+
+create table workout_charges (
+    id serial primary key,
+    workout_id integer not null unique,
+    kind varchar(20) not null check (kind in ('late_cancel', 'no_show')),
+    amount integer not null,
+    trainer_amount integer not null,
+    created_at timestamp not null DEFAULT NOW(),
+
+    foreign key (workout_id) references workouts(id) ON DELETE CASCADE
+);
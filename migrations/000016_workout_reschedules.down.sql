@@ -0,0 +1,3 @@
+drop table workout_reschedules;
+
+alter table workouts drop column original_date;
@@ -0,0 +1,14 @@
+alter table workouts add column original_date timestamp; -- set when the workout is moved for the first time
+
+create table workout_reschedules (
+    id serial primary key,
+    workout_id integer not null,
+    from_date timestamp not null,
+    to_date timestamp not null,
+    actor_id integer not null,
+    actor_role varchar(20) not null,
+    reason text not null,
+    moved_at timestamp not null DEFAULT NOW(),
+
+    foreign key (workout_id) references workouts(id) ON DELETE CASCADE
+);
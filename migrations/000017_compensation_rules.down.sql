@@ -0,0 +1,3 @@
+drop table compensation_tiers;
+
+drop table compensation_rules;
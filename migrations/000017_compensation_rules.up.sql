@@ -0,0 +1,27 @@
+-- A rule without trainer_id applies to every trainer, a rule without
+-- workout_type_id to every workout type. The most specific rule wins.
+create table compensation_rules (
+    id serial primary key,
+    trainer_id integer,
+    workout_type_id integer,
+
+    kind varchar(20) not null check (kind in ('percent', 'fixed', 'tiered')),
+    percent integer check (percent between 0 and 100), -- for percent rules
+    amount integer check (amount >= 0),                -- for fixed rules, per session
+
+    foreign key (trainer_id) references trainers(id) ON DELETE CASCADE,
+    foreign key (workout_type_id) references workout_types(id) ON DELETE CASCADE
+);
+
+create unique index compensation_rules_scope on compensation_rules (COALESCE(trainer_id, 0), COALESCE(workout_type_id, 0));
+
+-- The tier with the largest min_sessions the trainer reached in a month
+-- sets the percent for all sessions of that month.
+create table compensation_tiers (
+    rule_id integer not null,
+    min_sessions integer not null check (min_sessions >= 0),
+    percent integer not null check (percent between 0 and 100),
+
+    primary key (rule_id, min_sessions),
+    foreign key (rule_id) references compensation_rules(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+drop table payroll_lines;
+
+drop table payroll_periods;
@@ -0,0 +1,36 @@
+create table payroll_periods (
+    id serial primary key,
+    admin_id integer not null,
+
+    starts_on date not null,
+    ends_on date not null check (ends_on >= starts_on),
+    status varchar(20) not null DEFAULT 'open' check (status in ('open', 'closed')),
+    created_at timestamp not null DEFAULT NOW(),
+    closed_at timestamp,
+
+    foreign key (admin_id) references admins(id) ON DELETE CASCADE,
+    constraint payroll_periods_no_overlap
+        exclude using gist (daterange(starts_on, ends_on, '[]') with &&)
+);
+
+-- Statement lines are copied when a period is closed so that later changes
+-- to rules, prices or workouts do not change what was paid.
+create table payroll_lines (
+    id serial primary key,
+    period_id integer not null,
+    trainer_id integer not null,
+    workout_id integer not null,
+    workout_type_id integer not null,
+
+    title varchar not null,
+    price integer not null,
+    date timestamp not null,
+    kind varchar(20) not null,
+    rate varchar not null,
+    amount integer not null,
+
+    foreign key (period_id) references payroll_periods(id) ON DELETE CASCADE,
+    foreign key (trainer_id) references trainers(id) ON DELETE CASCADE
+);
+
+create index payroll_lines_period on payroll_lines (period_id, trainer_id);
@@ -0,0 +1,7 @@
+alter table workout_types drop column membership_required;
+
+drop table membership_usages;
+
+drop table client_memberships;
+
+drop table membership_products;
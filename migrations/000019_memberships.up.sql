@@ -0,0 +1,49 @@
+-- A package gives a number of sessions, an unlimited pass any number of
+-- sessions, both for validity_days from the start of the membership.
+-- Products without workout_type_id cover every workout type.
+create table membership_products (
+    id serial primary key,
+    workout_type_id integer,
+
+    title varchar not null,
+    kind varchar(20) not null check (kind in ('package', 'unlimited')),
+    sessions integer check (sessions > 0), -- for packages
+    validity_days integer not null check (validity_days > 0),
+    price integer not null check (price >= 0),
+    active boolean not null DEFAULT true,
+
+    check ((kind = 'package') = (sessions is not null)),
+    foreign key (workout_type_id) references workout_types(id) ON DELETE CASCADE
+);
+
+create table client_memberships (
+    id serial primary key,
+    client_id integer not null,
+    product_id integer not null,
+    admin_id integer not null,
+
+    starts_on date not null,
+    ends_on date not null check (ends_on >= starts_on),
+    sessions_left integer check (sessions_left >= 0), -- NULL for unlimited passes
+    price integer not null, -- paid at purchase
+    purchased_at timestamp not null DEFAULT NOW(),
+
+    foreign key (client_id) references clients(id) ON DELETE CASCADE,
+    foreign key (product_id) references membership_products(id),
+    foreign key (admin_id) references admins(id) ON DELETE CASCADE
+);
+
+create index client_memberships_client on client_memberships (client_id, ends_on);
+
+-- Every completed workout paid with a membership uses one credit of it.
+create table membership_usages (
+    id serial primary key,
+    membership_id integer not null,
+    workout_id integer not null unique,
+    used_at timestamp not null DEFAULT NOW(),
+
+    foreign key (membership_id) references client_memberships(id) ON DELETE CASCADE,
+    foreign key (workout_id) references workouts(id) ON DELETE CASCADE
+);
+
+alter table workout_types add column membership_required boolean not null DEFAULT false;
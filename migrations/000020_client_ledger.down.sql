@@ -0,0 +1 @@
+drop table client_ledger;
@@ -0,0 +1,23 @@
+-- Payments are positive and charges negative, so the sum of a client's
+-- entries is their balance and a negative balance is a debt.
+create table client_ledger (
+    id serial primary key,
+    client_id integer not null,
+    admin_id integer, -- who recorded a payment
+    workout_id integer,
+    membership_id integer,
+
+    kind varchar(20) not null check (kind in ('workout', 'late_cancel', 'no_show', 'membership', 'payment')),
+    method varchar(20) check (method in ('cash', 'card', 'transfer')), -- for payments
+    amount integer not null,
+    note varchar,
+    created_at timestamp not null DEFAULT NOW(),
+
+    check ((kind = 'payment') = (method is not null)),
+    foreign key (client_id) references clients(id) ON DELETE CASCADE,
+    foreign key (admin_id) references admins(id) ON DELETE SET NULL,
+    foreign key (workout_id) references workouts(id) ON DELETE SET NULL,
+    foreign key (membership_id) references client_memberships(id) ON DELETE SET NULL
+);
+
+create index client_ledger_client on client_ledger (client_id, created_at);
@@ -0,0 +1,2 @@
+alter table waitlist_entries drop column promotion_failed_at;
+alter table waitlist_entries drop column promotion_error;
@@ -0,0 +1,4 @@
+-- Why the last automatic promotion of a waiting entry failed, for staff
+-- to follow up on.
+alter table waitlist_entries add column promotion_error varchar;
+alter table waitlist_entries add column promotion_failed_at timestamp;
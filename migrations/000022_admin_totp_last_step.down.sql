@@ -0,0 +1 @@
+alter table admins drop column totp_last_step;
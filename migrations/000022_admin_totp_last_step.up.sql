@@ -0,0 +1,3 @@
+-- The time-step of the last accepted TOTP code, so that a code cannot be
+-- used twice within its window.
+alter table admins add column totp_last_step bigint;
@@ -0,0 +1,5 @@
+update waitlist_entries set status = 'left' where status = 'expired';
+
+alter table waitlist_entries drop constraint waitlist_entries_status_check;
+alter table waitlist_entries add constraint waitlist_entries_status_check
+    check (status in ('waiting', 'promoted', 'left'));
@@ -0,0 +1,5 @@
+-- Entries for slots and classes that have already started can no longer be
+-- promoted and are expired.
+alter table waitlist_entries drop constraint waitlist_entries_status_check;
+alter table waitlist_entries add constraint waitlist_entries_status_check
+    check (status in ('waiting', 'promoted', 'left', 'expired'));
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) createClassSession(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var class models.ClassSessionRequest
+	if err := c.BindJSON(&class); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.CreateClassSession(c, actor, class); err != nil {
+		abortWorkoutError(c, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) deleteClassSession(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.DeleteClassSession(c, id); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) getClassSessionByID(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	class, err := h.services.GetClassSessionByID(c, actor, id)
+	if err != nil {
+		abortWorkoutError(c, err)
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, class)
+}
+
+func (h *Handler) getClassSessionsByDate(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	date, err := time.Parse(time.RFC3339, c.Query("date"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	classes, err := h.services.GetClassSessionsByDate(c, actor, truncateDate(date))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, classes)
+}
+
+func (h *Handler) getClassSessionsByInterval(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := time.Parse(time.RFC3339, c.Query("to"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	classes, err := h.services.GetClassSessionsByInterval(c, actor, truncateDate(from), truncateDate(to))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, classes)
+}
+
+func (h *Handler) bookClass(c *gin.Context) {
+	var booking models.ClassBooking
+	if err := c.BindJSON(&booking); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.BookClass(c, booking); err != nil {
+		abortWorkoutError(c, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) changeClassStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := models.WorkoutStatus(c.Query("status"))
+	if err := h.services.ChangeClassStatus(c, id, status); err != nil {
+		abortWorkoutError(c, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) changeAttendeeStatus(c *gin.Context) {
+	classID, err := strconv.Atoi(c.Query("class_id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	clientID, err := strconv.Atoi(c.Query("client_id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := models.WorkoutStatus(c.Query("status"))
+	if err := h.services.ChangeAttendeeStatus(c, classID, clientID, status); err != nil {
+		abortWorkoutError(c, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+// truncateDate drops the time of day, the list endpoints compare dates only.
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/Hymiside/fitness-api/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) setCompensationRule(c *gin.Context) {
+	var rule models.CompensationRule
+	if err := c.BindJSON(&rule); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.SetCompensationRule(c, rule); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRule):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrTrainerNotFound), errors.Is(err, service.ErrWorkoutTypeNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) deleteCompensationRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.DeleteCompensationRule(c, id); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) getCompensationRules(c *gin.Context) {
+	rules, err := h.services.GetCompensationRules(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, rules)
+}
+
+func (h *Handler) getEarnings(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	trainerID, _ := strconv.Atoi(c.Query("trainer_id"))
+	groupBy := c.DefaultQuery("group_by", models.GroupByDay)
+
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := time.Parse(time.RFC3339, c.Query("to"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	earnings, err := h.services.GetEarnings(c, actor, trainerID, from, to, groupBy)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRange) || errors.Is(err, service.ErrInvalidGroupBy) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, earnings)
+}
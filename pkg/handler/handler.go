@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -13,16 +14,30 @@ import (
 	"github.com/gocraft/dbr/v2"
 )
 
+// authenticator resolves an access token to the user and the role it was issued to.
+type authenticator interface {
+	Authenticate(ctx context.Context, token string) (int, string, error)
+}
+
 type Handler struct {
 	services *service.Service
+	auth     authenticator
+	// trustedProxies are the addresses allowed to report the client IP
+	// in X-Forwarded-For. Without them the peer address is used.
+	trustedProxies []string
 }
 
-func NewHandler(services *service.Service) *Handler {
-	return &Handler{services: services}
+func NewHandler(services *service.Service, trustedProxies []string) *Handler {
+	return &Handler{services: services, auth: services, trustedProxies: trustedProxies}
 }
 
-func (h *Handler) InitRoutes() *gin.Engine {
+// InitRoutes builds the router. It fails when a trusted proxy is neither
+// an IP address nor a CIDR.
+func (h *Handler) InitRoutes() (*gin.Engine, error) {
 	router := gin.New()
+	if err := router.SetTrustedProxies(h.trustedProxies); err != nil {
+		return nil, err
+	}
 	router.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		return fmt.Sprintf("%s - [%s]	REQUEST: %s %s    STATUS-CODE: %d    LATENSY: %s\n",
 			param.ClientIP,
@@ -37,48 +52,122 @@ func (h *Handler) InitRoutes() *gin.Engine {
 	auth := router.Group("/auth")
 	{
 		auth.POST("/admin/sign-in", h.signInAdmin)
+		auth.POST("/admin/sign-in/totp", h.signInAdminTOTP)
 		auth.POST("/trainer/sign-in", h.signInTrainer)
+		auth.POST("/client/code", h.sendClientCode)
+		auth.POST("/client/sign-in", h.signInClient)
+		auth.POST("/refresh", h.refreshTokens)
+		auth.POST("/logout", h.logout)
 	}
 
+	sudo := h.allow(service.RoleSudo)
+	staff := h.allow(service.RoleSudo, service.RoleAdmin)
+	trainer := h.allow(service.RoleTrainer)
+	everyone := h.allow(service.RoleSudo, service.RoleAdmin, service.RoleTrainer)
+	client := h.allow(service.RoleClient)
+
 	api := router.Group("/fitness", h.userIdentity)
 	{
-		api.GET("/admin", h.getAdminByID)  // ?id=1
-		api.GET("/admin/list", h.getAdmins)
-		api.GET("/admin/delete", h.deleteAdmin) // ?id=1
-		api.POST("/admin/create", h.createAdmin)
-		api.GET("/admin/type", h.getAdminType)
-
-		api.GET("/trainer", h.getTrainerByID)  // ?id=1
-		api.POST("/trainer/create", h.createTrainer)
-		api.GET("/trainer/delete", h.deleteTrainer) // ?id=1
-		api.GET("/trainer/list", h.getTrainers)
-		api.GET("/trainer/cash/day", h.GetCashByDay)
-		api.GET("/trainer/cash/month", h.GetCashByMonth)
+		api.GET("/admin", staff, h.getAdminByID)  // ?id=1
+		api.GET("/admin/list", staff, h.getAdmins)
+		api.GET("/admin/delete", sudo, h.deleteAdmin) // ?id=1
+		api.POST("/admin/create", sudo, h.createAdmin)
+		api.GET("/admin/type", staff, h.getAdminType)
+		api.POST("/admin/password", staff, h.changeAdminPassword)
+		api.POST("/admin/totp/enroll", staff, h.enrollTOTP)
+		api.POST("/admin/totp/confirm", staff, h.confirmTOTP)
+		api.POST("/admin/totp/disable", staff, h.disableTOTP)
+		api.GET("/admin/lockouts", staff, h.getLoginAttempts)
+		api.GET("/admin/lockouts/clear", staff, h.clearLoginAttempts) // ?key=ip:127.0.0.1
+
+		api.GET("/trainer", staff, h.getTrainerByID)  // ?id=1
+		api.POST("/trainer/create", staff, h.createTrainer)
+		api.GET("/trainer/delete", staff, h.deleteTrainer) // ?id=1
+		api.POST("/trainer/token/regenerate", staff, h.regenerateTrainerToken)
+		api.GET("/trainer/list", staff, h.getTrainers)
+		api.POST("/trainer/availability", staff, h.setTrainerWorkingHours)
+		api.GET("/trainer/availability", everyone, h.getTrainerAvailability) // ?trainer_id=1
+		api.POST("/trainer/availability/exception/create", staff, h.createAvailabilityException)
+		api.GET("/trainer/availability/exception/delete", staff, h.deleteAvailabilityException) // ?id=1
+		api.GET("/trainer/slots", everyone, h.getFreeSlots) // ?trainer_id=1&workout_type_id=1&from=2023-12-23T00:00:00Z&to=2023-12-30T00:00:00Z
+		api.GET("/trainer/cash/day", trainer, h.GetCashByDay)
+		api.GET("/trainer/cash/month", trainer, h.GetCashByMonth)
+		api.GET("/trainer/earnings", everyone, h.getEarnings) // ?from=2023-12-01T00:00:00Z&to=2023-12-31T00:00:00Z&group_by=week&trainer_id=1
 		
 
-		api.POST("/client/create", h.createClient)
-		api.GET("/client", h.getClientByID)  // ?id=1
-		api.POST("/client/edit", h.updateClient)
-		api.GET("/client/list", h.getClients)
-
-		api.GET("/workout", h.getWorkoutByID)  // ?id=1
-		api.POST("/workout/create", h.createWorkout)
-		api.POST("/workout/edit", h.updateWorkout)
-		api.GET("/workout/delete", h.deleteWorkout) // ?id=1
-
-		api.GET("/workout/change-status", h.changeStatusWorkout)  // ?id=1&status=done
-		api.GET("/workout/list-by-date", h.getWorkoutsByDate)  // ?date=2023-12-23T15:04:05Z
-		api.GET("/workout/list-by-interval", h.getWorkoutsByInterval)  // ?from=2023-12-23T15:04:05Z&to=2023-12-23T15:04:05Z
-		api.GET("/workout/list", h.getWorkouts)  // ?trainer_id=1 or ?client_id=1
-
-		api.GET("/workout/type", h.getWorkoutTypeByID)  // ?id=1
-		api.POST("/workout/type/create", h.createWorkoutType)
-		api.GET("/workout/type/edit", h.updateWorkoutType)
-		api.GET("/workout/type/delete", h.deleteWorkoutType)  // ?id=1
-		api.GET("/workout/type/list", h.getWorkoutTypes)
+		api.POST("/client/create", staff, h.createClient)
+		api.GET("/client", everyone, h.getClientByID)  // ?id=1
+		api.POST("/client/edit", staff, h.updateClient)
+		api.GET("/client/list", everyone, h.getClients)
+		api.GET("/client/memberships", everyone, h.getClientMemberships) // ?client_id=1
+		api.GET("/client/ledger", staff, h.getClientLedger)              // ?client_id=1
+		api.GET("/client/debtors", staff, h.getDebtors)
+		api.POST("/payment/create", staff, h.recordPayment)
+
+		api.GET("/workout", everyone, h.getWorkoutByID)  // ?id=1
+		api.POST("/workout/create", staff, h.createWorkout)
+		api.POST("/workout/edit", staff, h.updateWorkout)
+		api.GET("/workout/delete", staff, h.deleteWorkout) // ?id=1
+		api.POST("/workout/reschedule", staff, h.rescheduleWorkout)
+		api.POST("/workout/series/create", staff, h.createWorkoutSeries)
+		api.POST("/workout/series/edit", staff, h.editWorkoutSeries)
+		api.POST("/workout/series/cancel", staff, h.cancelWorkoutSeries)
+
+		api.GET("/workout/change-status", staff, h.changeStatusWorkout)  // ?id=1&status=canceled&canceled_at=2023-12-23T09:00:00Z&waive=false
+		api.GET("/workout/list-by-date", everyone, h.getWorkoutsByDate)  // ?date=2023-12-23T15:04:05Z
+		api.GET("/workout/list-by-interval", everyone, h.getWorkoutsByInterval)  // ?from=2023-12-23T15:04:05Z&to=2023-12-23T15:04:05Z
+		api.GET("/workout/list", everyone, h.getWorkouts)  // ?trainer_id=1 or ?client_id=1
+
+		api.GET("/class", everyone, h.getClassSessionByID) // ?id=1
+		api.POST("/class/create", staff, h.createClassSession)
+		api.GET("/class/delete", staff, h.deleteClassSession)       // ?id=1
+		api.GET("/class/change-status", staff, h.changeClassStatus) // ?id=1&status=canceled
+		api.POST("/class/book", staff, h.bookClass)
+		api.GET("/class/attendee/change-status", staff, h.changeAttendeeStatus)    // ?class_id=1&client_id=1&status=done
+		api.GET("/class/list-by-date", everyone, h.getClassSessionsByDate)         // ?date=2023-12-23T15:04:05Z
+		api.GET("/class/list-by-interval", everyone, h.getClassSessionsByInterval) // ?from=2023-12-23T15:04:05Z&to=2023-12-23T15:04:05Z
+
+		api.POST("/waitlist/join", staff, h.joinWaitlist)
+		api.GET("/waitlist", everyone, h.getWaitlist)      // ?class_id=1 or ?trainer_id=1&date=2023-12-23T15:00:00Z
+		api.GET("/waitlist/leave", staff, h.leaveWaitlist) // ?id=1
+
+		api.GET("/policy/cancellation", staff, h.getCancellationPolicy)
+		api.POST("/policy/cancellation", sudo, h.updateCancellationPolicy)
+
+		api.POST("/compensation/rule", sudo, h.setCompensationRule)
+		api.GET("/compensation/rule/delete", sudo, h.deleteCompensationRule) // ?id=1
+		api.GET("/compensation/rules", staff, h.getCompensationRules)
+
+		api.POST("/payroll/period/create", staff, h.createPayrollPeriod)
+		api.GET("/payroll/period/close", staff, h.closePayrollPeriod) // ?id=1
+		api.GET("/payroll/period/list", staff, h.getPayrollPeriods)
+		api.GET("/payroll/statement", everyone, h.getPayrollStatement)    // ?period_id=1&trainer_id=1
+		api.GET("/payroll/statement/list", staff, h.getPayrollStatements) // ?period_id=1
+
+		api.GET("/resource", everyone, h.getResourceByID) // ?id=1
+		api.POST("/resource/create", staff, h.createResource)
+		api.POST("/resource/edit", staff, h.updateResource)
+		api.GET("/resource/delete", staff, h.deleteResource) // ?id=1
+		api.GET("/resource/list", everyone, h.getResources)
+
+		api.POST("/membership/product/create", staff, h.createMembershipProduct)
+		api.POST("/membership/product/edit", staff, h.updateMembershipProduct)
+		api.GET("/membership/product/list", everyone, h.getMembershipProducts)
+		api.POST("/membership/purchase", staff, h.purchaseMembership)
+
+		api.GET("/workout/type", everyone, h.getWorkoutTypeByID)  // ?id=1
+		api.POST("/workout/type/create", staff, h.createWorkoutType)
+		api.GET("/workout/type/edit", staff, h.updateWorkoutType)
+		api.GET("/workout/type/delete", staff, h.deleteWorkoutType)  // ?id=1
+		api.GET("/workout/type/list", everyone, h.getWorkoutTypes)
+
+		api.GET("/me/workouts/upcoming", client, h.getMyUpcomingWorkouts)
+		api.GET("/me/workouts/past", client, h.getMyPastWorkouts)
+		api.GET("/me/memberships", client, h.getClientMemberships)
+		api.GET("/me/ledger", client, h.getClientLedger)
 	}
 
-	return router
+	return router, nil
 }
 
 func (h *Handler) getWorkoutTypeByID(c *gin.Context) {
@@ -114,13 +203,49 @@ func (h *Handler) getAdminByID(c *gin.Context) {
 }
 
 func (h *Handler) createAdmin(c *gin.Context) {
-	admin := models.Admin{}
+	admin := models.AdminInput{}
 	if err := c.BindJSON(&admin); err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	if err := h.services.CreateAdmin(c, admin); err != nil {
+		if errors.Is(err, service.ErrEmptyPwd) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) changeAdminPassword(c *gin.Context) {
+	data, err := getData(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var passwordData models.PasswordChange
+	if err := c.BindJSON(&passwordData); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.services.ChangeAdminPassword(c, data["userID"].(int), passwordData.OldPassword, passwordData.NewPassword)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPwd) || errors.Is(err, service.ErrEmptyPwd) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, dbr.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
 	c.AbortWithStatus(http.StatusOK)
@@ -145,6 +270,106 @@ func (h *Handler) deleteAdmin(c *gin.Context) {
 	c.AbortWithStatus(http.StatusOK)
 }
 
+func (h *Handler) enrollTOTP(c *gin.Context) {
+	data, err := getData(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := h.services.EnrollTOTP(c, data["userID"].(int))
+	if err != nil {
+		if errors.Is(err, service.ErrTOTPEnabled) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, enrollment)
+}
+
+func (h *Handler) confirmTOTP(c *gin.Context) {
+	data, err := getData(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var codeData models.TOTPCode
+	if err := c.BindJSON(&codeData); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.services.ConfirmTOTP(c, data["userID"].(int), codeData.Code)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTOTP) || errors.Is(err, service.ErrTOTPNotEnrolled) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrTOTPEnabled) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, codes)
+}
+
+func (h *Handler) disableTOTP(c *gin.Context) {
+	data, err := getData(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var codeData models.TOTPCode
+	if err := c.BindJSON(&codeData); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.DisableTOTP(c, data["userID"].(int), codeData.Code); err != nil {
+		if errors.Is(err, service.ErrInvalidTOTP) || errors.Is(err, service.ErrTOTPNotEnabled) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) getLoginAttempts(c *gin.Context) {
+	attempts, err := h.services.GetLoginAttempts(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, attempts)
+}
+
+func (h *Handler) clearLoginAttempts(c *gin.Context) {
+	key, ok := c.GetQuery("key")
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "key not found"})
+		return
+	}
+
+	if err := h.services.ClearLoginAttempts(c, key); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
 func (h *Handler) GetCashByMonth(c *gin.Context) {
 	data, _ := getData(c)
 	trainerID := data["userID"].(int)
@@ -182,54 +407,172 @@ func (h *Handler) getAdminType(c *gin.Context) {
 }
 
 func (h *Handler) signInAdmin(c *gin.Context) {
-	var data models.Admin
+	var data models.AdminSignIn
 
 	if err := c.BindJSON(&data); err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	token, err := h.services.GenerateTokenForAdmin(c, data.Login, data.Password)
+	tokens, err := h.services.GenerateTokenForAdmin(c, data.Login, data.Password, c.ClientIP())
 	if err != nil {
-		if errors.Is(err, service.ErrInvalidPwd) {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
 
-		if errors.Is(err, dbr.ErrNotFound) {
-			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, service.ErrTooManyAttempts) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
 			return
 		}
 
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.AbortWithStatusJSON(http.StatusOK, gin.H{"token": token})
+	c.AbortWithStatusJSON(http.StatusOK, tokens)
+}
+
+func (h *Handler) signInAdminTOTP(c *gin.Context) {
+	var data models.MFASignIn
+
+	if err := c.BindJSON(&data); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.services.CompleteAdminSignIn(c, data.MFAToken, data.Code, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) || errors.Is(err, service.ErrParseJWT) ||
+			errors.Is(err, service.ErrTOTPNotEnabled) || errors.Is(err, dbr.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		if errors.Is(err, service.ErrTooManyAttempts) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusOK, tokens)
 }
 
 func (h *Handler) signInTrainer(c *gin.Context) {
-	var data models.Trainer
+	var data models.TrainerSignIn
 
 	if err := c.BindJSON(&data); err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	token, err := h.services.GenerateTokenForTrainer(c, data.Token)
+	tokens, err := h.services.GenerateTokenForTrainer(c, data.Token, c.ClientIP())
 	if err != nil {
-		if errors.Is(err, dbr.ErrNotFound) {
-			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		if errors.Is(err, service.ErrTooManyAttempts) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
 			return
 		}
 
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.AbortWithStatusJSON(http.StatusOK, gin.H{"token": token})
+	c.AbortWithStatusJSON(http.StatusOK, tokens)
+}
+
+func (h *Handler) sendClientCode(c *gin.Context) {
+	var data models.ClientCodeRequest
+
+	if err := c.BindJSON(&data); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.SendClientCode(c, data.Contact, c.ClientIP()); err != nil {
+		if errors.Is(err, service.ErrTooManyAttempts) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrNoSender) {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) signInClient(c *gin.Context) {
+	var data models.ClientSignIn
+
+	if err := c.BindJSON(&data); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.services.GenerateTokenForClient(c, data.Contact, data.Code, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		if errors.Is(err, service.ErrTooManyAttempts) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusOK, tokens)
+}
+
+func (h *Handler) refreshTokens(c *gin.Context) {
+	var data models.RefreshRequest
+
+	if err := c.BindJSON(&data); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.services.RefreshTokens(c, data.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefresh) || errors.Is(err, service.ErrSessionRevoked) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusOK, tokens)
+}
+
+func (h *Handler) logout(c *gin.Context) {
+	var data models.RefreshRequest
+
+	if err := c.BindJSON(&data); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.Logout(c, data.RefreshToken); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.AbortWithStatus(http.StatusOK)
 }
 
 func (h *Handler) createTrainer(c *gin.Context) {
-	var trainerData models.Trainer
+	var trainerData models.TrainerInput
 	if err := c.BindJSON(&trainerData); err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -244,6 +587,26 @@ func (h *Handler) createTrainer(c *gin.Context) {
 	c.AbortWithStatusJSON(http.StatusOK, gin.H{"token": token})
 }
 
+func (h *Handler) regenerateTrainerToken(c *gin.Context) {
+	var tokenData models.TrainerTokenRequest
+	if err := c.BindJSON(&tokenData); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.services.RegenerateTrainerToken(c, tokenData.ID, tokenData.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, gin.H{"token": token})
+}
+
 func (h *Handler) deleteTrainer(c *gin.Context) {
 	trainerIDdata, _ := c.GetQuery("id")
 	trainerID, err := strconv.Atoi(trainerIDdata)
@@ -325,7 +688,13 @@ func (h *Handler) updateClient(c *gin.Context) {
 }
 
 func (h *Handler) getClients(c *gin.Context) {
-	clients, err := h.services.GetClients(c)
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	clients, err := h.services.GetClients(c, actor)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -335,6 +704,12 @@ func (h *Handler) getClients(c *gin.Context) {
 }
 
 func (h *Handler) getClientByID(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
 	queryData, _ := c.GetQuery("id")
 	clientID, err := strconv.Atoi(queryData)
 	if err != nil {
@@ -342,7 +717,7 @@ func (h *Handler) getClientByID(c *gin.Context) {
 		return
 	}
 
-	client, err := h.services.GetClientByID(c, clientID)
+	client, err := h.services.GetClientByID(c, actor, clientID)
 	if err != nil {
 		if errors.Is(err, dbr.ErrNotFound) {
 			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "not found"})
@@ -371,7 +746,7 @@ func (h *Handler) createWorkout(c *gin.Context) {
 	workoutData.AdminID = data["userID"].(int)
 	err = h.services.CreateWorkout(c, workoutData)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWorkoutError(c, err)
 		return
 	}
 
@@ -387,9 +762,64 @@ func (h *Handler) updateWorkout(c *gin.Context) {
 
 	err := h.services.UpdateWorkout(c, workoutData)
 	if err != nil {
+		abortWorkoutError(c, err)
+		return
+	}
+	c.AbortWithStatus(http.StatusOK)
+}
+
+// abortWorkoutError answers with the status matching an error returned
+// while scheduling a workout or changing its status.
+func abortWorkoutError(c *gin.Context, err error) {
+	var conflict *service.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		body := gin.H{"error": err.Error(), "conflicting_workout_ids": conflict.WorkoutIDs}
+		if len(conflict.ClassIDs) != 0 {
+			body["conflicting_class_ids"] = conflict.ClassIDs
+		}
+		c.AbortWithStatusJSON(http.StatusConflict, body)
+	case errors.Is(err, service.ErrDateRequired), errors.Is(err, service.ErrInvalidStatus),
+		errors.Is(err, service.ErrInvalidCanceledAt),
+		errors.Is(err, service.ErrInvalidSeries), errors.Is(err, service.ErrSeriesTooLong),
+		errors.Is(err, service.ErrInvalidScope), errors.Is(err, service.ErrInvalidCapacity),
+		errors.Is(err, service.ErrWaitlistTarget), errors.Is(err, service.ErrResourceTooSmall),
+		errors.Is(err, service.ErrReasonRequired):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrClientNotFound), errors.Is(err, service.ErrTrainerNotFound),
+		errors.Is(err, service.ErrWorkoutTypeNotFound), errors.Is(err, dbr.ErrNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrIllegalTransition), errors.Is(err, service.ErrNotInSeries),
+		errors.Is(err, service.ErrTrainerUnavailable), errors.Is(err, service.ErrClassFull),
+		errors.Is(err, service.ErrClassClosed), errors.Is(err, service.ErrAlreadyBooked),
+		errors.Is(err, service.ErrPlaceAvailable), errors.Is(err, service.ErrAlreadyWaiting),
+		errors.Is(err, service.ErrNotWaiting), errors.Is(err, service.ErrNotReschedulable),
+		errors.Is(err, service.ErrNotDeletable),
+		errors.Is(err, service.ErrPeriodClosed), errors.Is(err, service.ErrNoCredit):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *Handler) rescheduleWorkout(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.RescheduleRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+
+	if err := h.services.RescheduleWorkout(c, actor, req); err != nil {
+		abortWorkoutError(c, err)
+		return
+	}
+
 	c.AbortWithStatus(http.StatusOK)
 }
 
@@ -403,7 +833,7 @@ func (h *Handler) deleteWorkout(c *gin.Context) {
 
 	err = h.services.DeleteWorkout(c, workoutID)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWorkoutError(c, err)
 		return
 	}
 
@@ -411,10 +841,13 @@ func (h *Handler) deleteWorkout(c *gin.Context) {
 }
 
 func (h *Handler) getWorkouts(c *gin.Context) {
-	var (
-		trainerID int
-		err error
-	)
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var trainerID int
 
 	queryTrainerID, ok := c.GetQuery("trainer_id")
 	if ok {
@@ -435,7 +868,7 @@ func (h *Handler) getWorkouts(c *gin.Context) {
 		}
 	}
 
-	workouts, err := h.services.GetWorkouts(c, trainerID, clientID)
+	workouts, err := h.services.GetWorkouts(c, actor, trainerID, clientID)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -453,6 +886,10 @@ func (h *Handler) createWorkoutType(c *gin.Context) {
 
 	err := h.services.CreateWorkoutType(c, workoutTypeData)
 	if err != nil {
+		if errors.Is(err, service.ErrResourceNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -469,6 +906,10 @@ func (h *Handler) updateWorkoutType(c *gin.Context) {
 
 	err := h.services.UpdateWorkoutType(c, workoutTypeData)
 	if err != nil {
+		if errors.Is(err, service.ErrResourceNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -504,18 +945,12 @@ func (h *Handler) getWorkoutTypes(c *gin.Context) {
 }
 
 func (h *Handler) getWorkoutsByDate(c *gin.Context) {
-	data, err := getData(c)
+	actor, err := getActor(c)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	var trainerID int
-	if data["role"] != "admin" {
-		trainerID = data["userID"].(int)
-	}
-
-
 	dateData, ok := c.GetQuery("date")
 	if !ok {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "date not found"})
@@ -529,7 +964,7 @@ func (h *Handler) getWorkoutsByDate(c *gin.Context) {
 		return
 	}
 
-	workouts, err := h.services.GetWorkoutsByDate(c, t, trainerID)
+	workouts, err := h.services.GetWorkoutsByDate(c, actor, t)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -538,17 +973,11 @@ func (h *Handler) getWorkoutsByDate(c *gin.Context) {
 }
 
 func (h *Handler) getWorkoutsByInterval(c *gin.Context) {
-	data, err := getData(c)
+	actor, err := getActor(c)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
-
-	var trainerID int
-	if data["role"] != "admin" {
-		trainerID = data["userID"].(int)
-	}
-
 	dateFromData, ok := c.GetQuery("from")
 	if !ok {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "from not found"})
@@ -576,7 +1005,7 @@ func (h *Handler) getWorkoutsByInterval(c *gin.Context) {
 		return
 	}
 
-	workouts, err := h.services.GetWorkoutsByInterval(c, tFrom, tTo, trainerID)
+	workouts, err := h.services.GetWorkoutsByInterval(c, actor, tFrom, tTo)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -586,6 +1015,12 @@ func (h *Handler) getWorkoutsByInterval(c *gin.Context) {
 }
 
 func (h *Handler) getWorkoutByID(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
 	data, _ := c.GetQuery("id")
 	workoutID, err := strconv.Atoi(data)
 	if err != nil {
@@ -593,7 +1028,7 @@ func (h *Handler) getWorkoutByID(c *gin.Context) {
 		return
 	}
 
-	workout, err := h.services.GetWorkoutByID(c, workoutID)
+	workout, err := h.services.GetWorkoutByID(c, actor, workoutID)
 	if err != nil {
 		if errors.Is(err, dbr.ErrNotFound) {
 			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -607,6 +1042,12 @@ func (h *Handler) getWorkoutByID(c *gin.Context) {
 }
 
 func (h *Handler) changeStatusWorkout(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
 	workoutIDdata, _ := c.GetQuery("id")
 	status, _ := c.GetQuery("status")
 
@@ -615,11 +1056,51 @@ func (h *Handler) changeStatusWorkout(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	
-	if err := h.services.ChangeStatusWorkout(c, workoutID, status); err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+	req := models.StatusChangeRequest{WorkoutID: workoutID, Status: models.WorkoutStatus(status)}
+	if raw, ok := c.GetQuery("canceled_at"); ok {
+		canceledAt, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.CanceledAt = &canceledAt
+	}
+	if raw, ok := c.GetQuery("waive"); ok {
+		if req.Waive, err = strconv.ParseBool(raw); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := h.services.ChangeStatusWorkout(c, actor, req); err != nil {
+		abortWorkoutError(c, err)
 		return
 	}
 
 	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) getMyUpcomingWorkouts(c *gin.Context) {
+	h.getMyWorkouts(c, true)
+}
+
+func (h *Handler) getMyPastWorkouts(c *gin.Context) {
+	h.getMyWorkouts(c, false)
+}
+
+func (h *Handler) getMyWorkouts(c *gin.Context, upcoming bool) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	workouts, err := h.services.GetClientWorkouts(c, actor, upcoming)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, workouts)
 }
\ No newline at end of file
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/Hymiside/fitness-api/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/gocraft/dbr/v2"
+)
+
+func (h *Handler) recordPayment(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var payment models.PaymentInput
+	if err := c.BindJSON(&payment); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.RecordPayment(c, actor, payment); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPayment):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrClientNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) getClientLedger(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientID, _ := strconv.Atoi(c.Query("client_id"))
+
+	entries, err := h.services.GetClientLedger(c, actor, clientID)
+	if err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, entries)
+}
+
+func (h *Handler) getDebtors(c *gin.Context) {
+	debtors, err := h.services.GetDebtors(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, debtors)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/Hymiside/fitness-api/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/gocraft/dbr/v2"
+)
+
+func (h *Handler) createMembershipProduct(c *gin.Context) {
+	var product models.MembershipProduct
+	if err := c.BindJSON(&product); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.CreateMembershipProduct(c, product); err != nil {
+		abortProductError(c, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) updateMembershipProduct(c *gin.Context) {
+	var product models.MembershipProduct
+	if err := c.BindJSON(&product); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.UpdateMembershipProduct(c, product); err != nil {
+		abortProductError(c, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func abortProductError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidProduct):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWorkoutTypeNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *Handler) getMembershipProducts(c *gin.Context) {
+	products, err := h.services.GetMembershipProducts(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, products)
+}
+
+func (h *Handler) purchaseMembership(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var purchase models.MembershipPurchase
+	if err := c.BindJSON(&purchase); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.PurchaseMembership(c, actor, purchase); err != nil {
+		switch {
+		case errors.Is(err, service.ErrClientNotFound), errors.Is(err, service.ErrProductNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrProductInactive):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) getClientMemberships(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientID, _ := strconv.Atoi(c.Query("client_id"))
+
+	memberships, err := h.services.GetClientMemberships(c, actor, clientID)
+	if err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, memberships)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Hymiside/fitness-api/pkg/service"
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,6 +13,7 @@ var (
 	ErrUserIdNotFound = errors.New("userId not found")
 	ErrParseJSON      = errors.New("error to parse json")
 	ErrInvalidRequest = errors.New("error invalid request")
+	ErrForbidden      = errors.New("forbidden")
 )
 
 const (
@@ -35,7 +37,7 @@ func (h *Handler) userIdentity(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token is empty"})
 		return
 	}
-	userID, role, err := h.services.ParseToken(headerParts[1])
+	userID, role, err := h.auth.Authenticate(c, headerParts[1])
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -46,10 +48,37 @@ func (h *Handler) userIdentity(c *gin.Context) {
 	})
 }
 
+// allow пропускает запрос дальше, только если роль пользователя есть в списке
+func (h *Handler) allow(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, err := getData(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		role, _ := data["role"].(string)
+		for _, allowed := range roles {
+			if role == allowed {
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": ErrForbidden.Error()})
+	}
+}
+
 func getData(c *gin.Context) (map[string]interface{}, error) {
 	data, ok := c.Get(userCtx)
 	if !ok {
 		return nil, ErrUserIdNotFound
 	}
 	return data.(map[string]interface{}), nil
-}
\ No newline at end of file
+}
+
+func getActor(c *gin.Context) (service.Actor, error) {
+	data, err := getData(c)
+	if err != nil {
+		return service.Actor{}, err
+	}
+	return service.Actor{ID: data["userID"].(int), Role: data["role"].(string)}, nil
+}
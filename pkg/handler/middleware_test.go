@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Hymiside/fitness-api/pkg/repository"
+	"github.com/Hymiside/fitness-api/pkg/sender"
+	"github.com/Hymiside/fitness-api/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/gocraft/dbr/v2"
+	_ "github.com/lib/pq"
+)
+
+// stubAuth treats the token as the name of the role it was issued to.
+type stubAuth struct{}
+
+func (stubAuth) Authenticate(_ context.Context, token string) (int, string, error) {
+	return 1, token, nil
+}
+
+// newTestRouter builds the routes with a service whose database is not
+// reachable, so requests that pass the middleware fail in the handlers
+// instead of touching real data.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+
+	db, err := dbr.Open("postgres", "postgres://test@127.0.0.1:1/test?sslmode=disable&connect_timeout=1", nil)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	services := service.NewService(repository.NewRepository(db), service.Config{}, sender.NewLogSender())
+	h := &Handler{services: services, auth: stubAuth{}}
+	router, err := h.InitRoutes()
+	if err != nil {
+		t.Fatalf("init routes: %v", err)
+	}
+	return router
+}
+
+var (
+	roles = []string{service.RoleSudo, service.RoleAdmin, service.RoleTrainer, service.RoleClient}
+
+	sudo     = []string{service.RoleSudo}
+	staff    = []string{service.RoleSudo, service.RoleAdmin}
+	trainer  = []string{service.RoleTrainer}
+	everyone = []string{service.RoleSudo, service.RoleAdmin, service.RoleTrainer}
+	client   = []string{service.RoleClient}
+)
+
+func TestAccessMatrix(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		method  string
+		path    string
+		allowed []string
+	}{
+		{http.MethodGet, "/fitness/admin", staff},
+		{http.MethodGet, "/fitness/admin/list", staff},
+		{http.MethodGet, "/fitness/admin/delete", sudo},
+		{http.MethodPost, "/fitness/admin/create", sudo},
+		{http.MethodGet, "/fitness/admin/type", staff},
+		{http.MethodPost, "/fitness/admin/password", staff},
+		{http.MethodPost, "/fitness/admin/totp/enroll", staff},
+		{http.MethodPost, "/fitness/admin/totp/confirm", staff},
+		{http.MethodPost, "/fitness/admin/totp/disable", staff},
+		{http.MethodGet, "/fitness/admin/lockouts", staff},
+		{http.MethodGet, "/fitness/admin/lockouts/clear", staff},
+		{http.MethodGet, "/fitness/trainer", staff},
+		{http.MethodPost, "/fitness/trainer/create", staff},
+		{http.MethodGet, "/fitness/trainer/delete", staff},
+		{http.MethodPost, "/fitness/trainer/token/regenerate", staff},
+		{http.MethodGet, "/fitness/trainer/list", staff},
+		{http.MethodPost, "/fitness/trainer/availability", staff},
+		{http.MethodGet, "/fitness/trainer/availability", everyone},
+		{http.MethodPost, "/fitness/trainer/availability/exception/create", staff},
+		{http.MethodGet, "/fitness/trainer/availability/exception/delete", staff},
+		{http.MethodGet, "/fitness/trainer/slots", everyone},
+		{http.MethodGet, "/fitness/trainer/cash/day", trainer},
+		{http.MethodGet, "/fitness/trainer/cash/month", trainer},
+		{http.MethodGet, "/fitness/trainer/earnings", everyone},
+		{http.MethodPost, "/fitness/client/create", staff},
+		{http.MethodGet, "/fitness/client", everyone},
+		{http.MethodPost, "/fitness/client/edit", staff},
+		{http.MethodGet, "/fitness/client/list", everyone},
+		{http.MethodGet, "/fitness/client/memberships", everyone},
+		{http.MethodGet, "/fitness/client/ledger", staff},
+		{http.MethodGet, "/fitness/client/debtors", staff},
+		{http.MethodPost, "/fitness/payment/create", staff},
+		{http.MethodGet, "/fitness/workout", everyone},
+		{http.MethodPost, "/fitness/workout/create", staff},
+		{http.MethodPost, "/fitness/workout/edit", staff},
+		{http.MethodGet, "/fitness/workout/delete", staff},
+		{http.MethodPost, "/fitness/workout/reschedule", staff},
+		{http.MethodPost, "/fitness/workout/series/create", staff},
+		{http.MethodPost, "/fitness/workout/series/edit", staff},
+		{http.MethodPost, "/fitness/workout/series/cancel", staff},
+		{http.MethodGet, "/fitness/workout/change-status", staff},
+		{http.MethodGet, "/fitness/workout/list-by-date", everyone},
+		{http.MethodGet, "/fitness/workout/list-by-interval", everyone},
+		{http.MethodGet, "/fitness/workout/list", everyone},
+		{http.MethodGet, "/fitness/class", everyone},
+		{http.MethodPost, "/fitness/class/create", staff},
+		{http.MethodGet, "/fitness/class/delete", staff},
+		{http.MethodGet, "/fitness/class/change-status", staff},
+		{http.MethodPost, "/fitness/class/book", staff},
+		{http.MethodGet, "/fitness/class/attendee/change-status", staff},
+		{http.MethodGet, "/fitness/class/list-by-date", everyone},
+		{http.MethodGet, "/fitness/class/list-by-interval", everyone},
+		{http.MethodPost, "/fitness/waitlist/join", staff},
+		{http.MethodGet, "/fitness/waitlist", everyone},
+		{http.MethodGet, "/fitness/waitlist/leave", staff},
+		{http.MethodGet, "/fitness/policy/cancellation", staff},
+		{http.MethodPost, "/fitness/policy/cancellation", sudo},
+		{http.MethodPost, "/fitness/compensation/rule", sudo},
+		{http.MethodGet, "/fitness/compensation/rule/delete", sudo},
+		{http.MethodGet, "/fitness/compensation/rules", staff},
+		{http.MethodPost, "/fitness/payroll/period/create", staff},
+		{http.MethodGet, "/fitness/payroll/period/close", staff},
+		{http.MethodGet, "/fitness/payroll/period/list", staff},
+		{http.MethodGet, "/fitness/payroll/statement", everyone},
+		{http.MethodGet, "/fitness/payroll/statement/list", staff},
+		{http.MethodGet, "/fitness/resource", everyone},
+		{http.MethodPost, "/fitness/resource/create", staff},
+		{http.MethodPost, "/fitness/resource/edit", staff},
+		{http.MethodGet, "/fitness/resource/delete", staff},
+		{http.MethodGet, "/fitness/resource/list", everyone},
+		{http.MethodPost, "/fitness/membership/product/create", staff},
+		{http.MethodPost, "/fitness/membership/product/edit", staff},
+		{http.MethodGet, "/fitness/membership/product/list", everyone},
+		{http.MethodPost, "/fitness/membership/purchase", staff},
+		{http.MethodGet, "/fitness/workout/type", everyone},
+		{http.MethodPost, "/fitness/workout/type/create", staff},
+		{http.MethodGet, "/fitness/workout/type/edit", staff},
+		{http.MethodGet, "/fitness/workout/type/delete", staff},
+		{http.MethodGet, "/fitness/workout/type/list", everyone},
+		{http.MethodGet, "/fitness/me/workouts/upcoming", client},
+		{http.MethodGet, "/fitness/me/workouts/past", client},
+		{http.MethodGet, "/fitness/me/memberships", client},
+		{http.MethodGet, "/fitness/me/ledger", client},
+	}
+
+	covered := make(map[string]bool, len(tests))
+	for _, tt := range tests {
+		covered[tt.method+" "+tt.path] = true
+	}
+	for _, route := range router.Routes() {
+		if strings.HasPrefix(route.Path, "/fitness/") && !covered[route.Method+" "+route.Path] {
+			t.Errorf("%s %s is missing from the access matrix", route.Method, route.Path)
+		}
+	}
+
+	for _, tt := range tests {
+		for _, role := range roles {
+			allowed := false
+			for _, r := range tt.allowed {
+				allowed = allowed || r == role
+			}
+
+			t.Run(tt.method+" "+tt.path+" as "+role, func(t *testing.T) {
+				req := httptest.NewRequest(tt.method, tt.path, nil)
+				req.Header.Set("Authorization", "Bearer "+role)
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				switch {
+				case !allowed && rec.Code != http.StatusForbidden:
+					t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+				case allowed && (rec.Code == http.StatusForbidden || rec.Code == http.StatusUnauthorized):
+					t.Errorf("status = %d, want the request to pass the middleware", rec.Code)
+				}
+			})
+		}
+	}
+}
+
+func TestAccessWithoutToken(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"no header", ""},
+		{"not bearer", "Basic sudo"},
+		{"empty token", "Bearer "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/fitness/admin/list", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestInitRoutesTrustedProxies(t *testing.T) {
+	tests := []struct {
+		proxies []string
+		wantErr bool
+	}{
+		{nil, false},
+		{[]string{"10.0.0.1", "10.0.1.0/24"}, false},
+		{[]string{"proxy.local"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.proxies, ","), func(t *testing.T) {
+			h := &Handler{trustedProxies: tt.proxies}
+			if _, err := h.InitRoutes(); (err != nil) != tt.wantErr {
+				t.Errorf("InitRoutes() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
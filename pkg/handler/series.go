@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) createWorkoutSeries(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input models.WorkoutSeriesRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series, err := h.services.CreateWorkoutSeries(c, actor, input)
+	if err != nil {
+		abortWorkoutError(c, err)
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, series)
+}
+
+func (h *Handler) editWorkoutSeries(c *gin.Context) {
+	var edit models.SeriesEdit
+	if err := c.BindJSON(&edit); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.EditWorkoutSeries(c, edit); err != nil {
+		abortWorkoutError(c, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) cancelWorkoutSeries(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var cancel models.SeriesCancel
+	if err := c.BindJSON(&cancel); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids, err := h.services.CancelWorkoutSeries(c, actor, cancel)
+	if err != nil {
+		abortWorkoutError(c, err)
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, gin.H{"canceled_workout_ids": ids})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) joinWaitlist(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var entry models.WaitlistEntry
+	if err := c.BindJSON(&entry); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.JoinWaitlist(c, actor, entry); err != nil {
+		abortWorkoutError(c, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
+
+func (h *Handler) getWaitlist(c *gin.Context) {
+	var (
+		entries []models.WaitlistEntry
+		err     error
+	)
+
+	if classData, ok := c.GetQuery("class_id"); ok {
+		classID, convErr := strconv.Atoi(classData)
+		if convErr != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": convErr.Error()})
+			return
+		}
+		entries, err = h.services.GetClassWaitlist(c, classID)
+	} else {
+		trainerID, convErr := strconv.Atoi(c.Query("trainer_id"))
+		if convErr != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": convErr.Error()})
+			return
+		}
+		date, parseErr := time.Parse(time.RFC3339, c.Query("date"))
+		if parseErr != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": parseErr.Error()})
+			return
+		}
+		entries, err = h.services.GetSlotWaitlist(c, trainerID, date)
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusOK, entries)
+}
+
+func (h *Handler) leaveWaitlist(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.services.LeaveWaitlist(c, id); err != nil {
+		abortWorkoutError(c, err)
+		return
+	}
+
+	c.AbortWithStatus(http.StatusOK)
+}
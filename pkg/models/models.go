@@ -3,32 +3,224 @@ package models
 import "time"
 
 type Admin struct {
-	ID        int    `json:"id" db:"id"`
-	Login     string `json:"login" db:"login"`
-	Password  string `json:"password" db:"password"`
-	FirstName string `json:"first_name" db:"first_name"`
-	LastName  string `json:"last_name" db:"last_name"`
-	Super     bool   `json:"super" db:"super"`
+	ID        int    `json:"-" db:"id"`
+	Login     string `json:"-" db:"login"`
+	Password  string `json:"-" db:"password"`
+	FirstName string `json:"-" db:"first_name"`
+	LastName  string `json:"-" db:"last_name"`
+	Super     bool   `json:"-" db:"super"`
+
+	TOTPSecret  *string `json:"-" db:"totp_secret"`
+	TOTPEnabled bool    `json:"-" db:"totp_enabled"`
+}
+
+type AdminInput struct {
+	Login     string `json:"login"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Super     bool   `json:"super"`
+}
+
+type AdminResponse struct {
+	ID          int    `json:"id"`
+	Login       string `json:"login"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	Super       bool   `json:"super"`
+	TOTPEnabled bool   `json:"totp_enabled"`
+}
+
+type AdminSignIn struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+func (a Admin) Response() AdminResponse {
+	return AdminResponse{
+		ID:          a.ID,
+		Login:       a.Login,
+		FirstName:   a.FirstName,
+		LastName:    a.LastName,
+		Super:       a.Super,
+		TOTPEnabled: a.TOTPEnabled,
+	}
+}
+
+type PasswordChange struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+type Session struct {
+	ID               int        `json:"id" db:"id"`
+	UserID           int        `json:"user_id" db:"user_id"`
+	Role             string     `json:"role" db:"role"`
+	RefreshTokenHash string     `json:"-" db:"refresh_token_hash"`
+	ExpiresAt        time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+type Tokens struct {
+	AccessToken  string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// MFAToken is returned instead of the tokens above while the second factor is still required.
+	MFAToken string `json:"mfa_token,omitempty"`
+}
+
+type MFASignIn struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type TOTPCode struct {
+	Code string `json:"code"`
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LoginAttempt struct {
+	Key           string     `json:"key" db:"key"`
+	Failures      int        `json:"failures" db:"failures"`
+	LockedUntil   *time.Time `json:"locked_until" db:"locked_until"`
+	LastFailureAt time.Time  `json:"last_failure_at" db:"last_failure_at"`
 }
 
 type Trainer struct {
+	ID             int        `json:"-" db:"id"`
+	TokenHash      string     `json:"-" db:"token_hash"`
+	TokenExpiresAt *time.Time `json:"-" db:"token_expires_at"`
+	FirstName      string     `json:"-" db:"first_name"`
+	LastName       string     `json:"-" db:"last_name"`
+}
+
+type TrainerInput struct {
+	FirstName      string     `json:"first_name"`
+	LastName       string     `json:"last_name"`
+	TokenExpiresAt *time.Time `json:"token_expires_at"`
+}
+
+type TrainerResponse struct {
+	ID             int        `json:"id"`
+	FirstName      string     `json:"first_name"`
+	LastName       string     `json:"last_name"`
+	TokenExpiresAt *time.Time `json:"token_expires_at"`
+}
+
+type TrainerSignIn struct {
+	Token string `json:"token"`
+}
+
+func (t Trainer) Response() TrainerResponse {
+	return TrainerResponse{
+		ID:             t.ID,
+		FirstName:      t.FirstName,
+		LastName:       t.LastName,
+		TokenExpiresAt: t.TokenExpiresAt,
+	}
+}
+
+type TrainerTokenRequest struct {
+	ID        int        `json:"id"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// WorkingHours is one interval of a trainer's weekly schedule.
+// Times are "15:04" in the same clock as workout dates.
+type WorkingHours struct {
 	ID        int    `json:"id" db:"id"`
-	Token     string `json:"token" db:"token"`
-	FirstName string `json:"first_name" db:"first_name"`
-	LastName  string `json:"last_name" db:"last_name"`
+	TrainerID int    `json:"-" db:"trainer_id"`
+	Weekday   int    `json:"weekday" db:"weekday"` // 0 is Sunday
+	StartsAt  string `json:"starts_at" db:"starts_at"`
+	EndsAt    string `json:"ends_at" db:"ends_at"`
+}
+
+type WorkingHoursInput struct {
+	TrainerID int            `json:"trainer_id"`
+	Hours     []WorkingHours `json:"hours"`
+}
+
+// AvailabilityException replaces the weekly hours on Date.
+// Without StartsAt and EndsAt the trainer does not work that day.
+type AvailabilityException struct {
+	ID        int       `json:"id" db:"id"`
+	TrainerID int       `json:"trainer_id" db:"trainer_id"`
+	Date      time.Time `json:"date" db:"date"`
+	StartsAt  *string   `json:"starts_at" db:"starts_at"`
+	EndsAt    *string   `json:"ends_at" db:"ends_at"`
+	Reason    string    `json:"reason" db:"reason"`
+}
+
+type Availability struct {
+	Hours      []WorkingHours          `json:"hours"`
+	Exceptions []AvailabilityException `json:"exceptions"`
+}
+
+type TimeSlot struct {
+	StartsAt time.Time `json:"starts_at" db:"date"`
+	EndsAt   time.Time `json:"ends_at" db:"ends_at"`
 }
 
 type Client struct {
-	ID          int    `json:"id" db:"id"`
-	FirstName   string `json:"first_name" db:"first_name"`
-	LastName    string `json:"last_name" db:"last_name"`
-	Surname string `json:"surname" db:"surname"`
+	ID          int     `json:"id" db:"id"`
+	FirstName   string  `json:"first_name" db:"first_name"`
+	LastName    string  `json:"last_name" db:"last_name"`
+	Surname     string  `json:"surname" db:"surname"`
+	PhoneNumber *string `json:"phone_number" db:"phone_number"`
+	Email       *string `json:"email" db:"email"`
+}
+
+type ClientLoginCode struct {
+	ClientID  int       `json:"client_id" db:"client_id"`
+	CodeHash  string    `json:"-" db:"code_hash"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+}
+
+type ClientCodeRequest struct {
+	// Contact is the client's phone number or email.
+	Contact string `json:"contact"`
+}
+
+type ClientSignIn struct {
+	Contact string `json:"contact"`
+	Code    string `json:"code"`
 }
 
 type WorkoutType struct {
 	ID    int    `json:"id" db:"id"`
 	Title string `json:"title" db:"title"`
 	Price int    `json:"price" db:"price"`
+	// Duration is the length of a session in minutes.
+	Duration int `json:"duration" db:"duration"`
+	// ResourceID is the room or equipment every session of the type takes.
+	ResourceID *int `json:"resource_id" db:"resource_id"`
+	// MembershipRequired types can only be completed with a membership credit.
+	// Types with an active product of their own require one regardless.
+	MembershipRequired bool `json:"membership_required" db:"membership_required"`
+}
+
+const (
+	ResourceRoom      = "room"
+	ResourceEquipment = "equipment"
+)
+
+// Resource is a room or a piece of equipment that hosts one session at a time.
+type Resource struct {
+	ID       int    `json:"id" db:"id"`
+	Title    string `json:"title" db:"title"`
+	Kind     string `json:"kind" db:"kind"`
+	Capacity int    `json:"capacity" db:"capacity"`
 }
 
 type Workout struct {
@@ -40,6 +232,242 @@ type Workout struct {
 	Date        time.Time   `json:"date" db:"date"`
 }
 
+type WorkoutStatus string
+
+const (
+	StatusPending  WorkoutStatus = "pending"
+	StatusDone     WorkoutStatus = "done"
+	StatusCanceled WorkoutStatus = "canceled"
+	StatusNoShow   WorkoutStatus = "no_show"
+)
+
+// workoutTransitions lists the statuses a workout may move to from each status.
+// Done, canceled and no-show workouts are final.
+var workoutTransitions = map[WorkoutStatus][]WorkoutStatus{
+	StatusPending: {StatusDone, StatusCanceled, StatusNoShow},
+}
+
+func (s WorkoutStatus) Valid() bool {
+	switch s {
+	case StatusPending, StatusDone, StatusCanceled, StatusNoShow:
+		return true
+	}
+	return false
+}
+
+func (s WorkoutStatus) CanTransitionTo(next WorkoutStatus) bool {
+	for _, allowed := range workoutTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+type WorkoutStatusChange struct {
+	ID         int           `json:"id" db:"id"`
+	WorkoutID  int           `json:"workout_id" db:"workout_id"`
+	FromStatus WorkoutStatus `json:"from_status" db:"from_status"`
+	ToStatus   WorkoutStatus `json:"to_status" db:"to_status"`
+	ActorID    int           `json:"actor_id" db:"actor_id"`
+	ActorRole  string        `json:"actor_role" db:"actor_role"`
+	ChangedAt  time.Time     `json:"changed_at" db:"changed_at"`
+
+	// Charge is the fee the change results in, it is saved together with the change.
+	Charge *WorkoutCharge `json:"-" db:"-"`
+	// MembershipID is the membership a completed workout uses a credit of.
+	MembershipID *int `json:"-" db:"-"`
+	// Ledger is what the client is charged for the change.
+	Ledger *LedgerEntry `json:"-" db:"-"`
+}
+
+// StatusChangeRequest moves a workout to Status. CanceledAt is when the
+// client actually canceled, if it was recorded later. Waive skips the
+// cancellation or no-show fee, for example when the studio canceled.
+type StatusChangeRequest struct {
+	WorkoutID  int
+	Status     WorkoutStatus
+	CanceledAt *time.Time
+	Waive      bool
+}
+
+type RescheduleRequest struct {
+	WorkoutID int       `json:"id"`
+	Date      time.Time `json:"date"`
+	Reason    string    `json:"reason"`
+}
+
+type WorkoutReschedule struct {
+	ID        int       `json:"id" db:"id"`
+	WorkoutID int       `json:"workout_id" db:"workout_id"`
+	FromDate  time.Time `json:"from_date" db:"from_date"`
+	ToDate    time.Time `json:"to_date" db:"to_date"`
+	ActorID   int       `json:"actor_id" db:"actor_id"`
+	ActorRole string    `json:"actor_role" db:"actor_role"`
+	Reason    string    `json:"reason" db:"reason"`
+	MovedAt   time.Time `json:"moved_at" db:"moved_at"`
+}
+
+const (
+	CompensationPercent = "percent"
+	CompensationFixed   = "fixed"
+	CompensationTiered  = "tiered"
+)
+
+// CompensationRule sets what a trainer earns for a completed workout.
+// Without TrainerID it applies to every trainer, without WorkoutTypeID
+// to every workout type.
+type CompensationRule struct {
+	ID            int                `json:"id" db:"id"`
+	TrainerID     *int               `json:"trainer_id" db:"trainer_id"`
+	WorkoutTypeID *int               `json:"workout_type_id" db:"workout_type_id"`
+	Kind          string             `json:"kind" db:"kind"`
+	Percent       *int               `json:"percent,omitempty" db:"percent"`
+	Amount        *int               `json:"amount,omitempty" db:"amount"`
+	Tiers         []CompensationTier `json:"tiers,omitempty" db:"-"`
+}
+
+type CompensationTier struct {
+	RuleID      int `json:"-" db:"rule_id"`
+	MinSessions int `json:"min_sessions" db:"min_sessions"`
+	Percent     int `json:"percent" db:"percent"`
+}
+
+// EarningKindSession marks earnings for a completed workout, other
+// earnings are a share of a charge and have the kind of the charge.
+const EarningKindSession = "session"
+
+// EarningLine is what a trainer earned for one workout. Rate describes
+// how the amount was computed.
+type EarningLine struct {
+	WorkoutID     int       `json:"workout_id" db:"workout_id"`
+	TrainerID     int       `json:"trainer_id" db:"trainer_id"`
+	WorkoutTypeID int       `json:"workout_type_id" db:"workout_type_id"`
+	Title         string    `json:"title" db:"title"`
+	Price         int       `json:"price" db:"price"`
+	Date          time.Time `json:"date" db:"date"`
+	Kind          string    `json:"kind" db:"kind"`
+	Rate          string    `json:"rate" db:"rate"`
+	Amount        int       `json:"amount" db:"amount"`
+}
+
+const (
+	GroupByDay   = "day"
+	GroupByWeek  = "week"
+	GroupByMonth = "month"
+)
+
+// EarningGroup sums what a trainer earned for one workout type during the
+// day, week or month starting at Period.
+type EarningGroup struct {
+	Period        time.Time `json:"period"`
+	TrainerID     int       `json:"trainer_id"`
+	WorkoutTypeID int       `json:"workout_type_id"`
+	Title         string    `json:"title"`
+	Sessions      int       `json:"sessions"`
+	Amount        int       `json:"amount"`
+}
+
+type Earnings struct {
+	Groups []EarningGroup `json:"groups"`
+	Total  int            `json:"total"`
+}
+
+const (
+	PayrollOpen   = "open"
+	PayrollClosed = "closed"
+)
+
+// PayrollPeriod is a span of days trainers are paid for at once. Closing it
+// freezes its statements and its workouts can no longer be changed.
+type PayrollPeriod struct {
+	ID        int        `json:"id" db:"id"`
+	AdminID   int        `json:"-" db:"admin_id"`
+	StartsOn  time.Time  `json:"starts_on" db:"starts_on"`
+	EndsOn    time.Time  `json:"ends_on" db:"ends_on"`
+	Status    string     `json:"status" db:"status"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ClosedAt  *time.Time `json:"closed_at" db:"closed_at"`
+}
+
+// PayrollStatement is what one trainer earned in a payroll period.
+type PayrollStatement struct {
+	PeriodID  int           `json:"period_id"`
+	TrainerID int           `json:"trainer_id"`
+	FirstName string        `json:"first_name"`
+	LastName  string        `json:"last_name"`
+	Lines     []EarningLine `json:"lines"`
+	Total     int           `json:"total"`
+}
+
+const (
+	MembershipPackage   = "package"
+	MembershipUnlimited = "unlimited"
+)
+
+// MembershipProduct is a package of sessions or an unlimited pass clients
+// can buy. Without WorkoutTypeID it covers every workout type.
+type MembershipProduct struct {
+	ID            int    `json:"id" db:"id"`
+	WorkoutTypeID *int   `json:"workout_type_id" db:"workout_type_id"`
+	Title         string `json:"title" db:"title"`
+	Kind          string `json:"kind" db:"kind"`
+	// Sessions is the number of sessions in a package.
+	Sessions     *int `json:"sessions,omitempty" db:"sessions"`
+	ValidityDays int  `json:"validity_days" db:"validity_days"`
+	Price        int  `json:"price" db:"price"`
+	Active       bool `json:"active" db:"active"`
+}
+
+type MembershipPurchase struct {
+	ClientID  int `json:"client_id"`
+	ProductID int `json:"product_id"`
+	// StartsOn defaults to the day of the purchase.
+	StartsOn *time.Time `json:"starts_on"`
+}
+
+// ClientMembership is a product bought by a client. SessionsLeft is nil
+// for unlimited passes.
+type ClientMembership struct {
+	ID            int       `json:"id" db:"id"`
+	ClientID      int       `json:"client_id" db:"client_id"`
+	ProductID     int       `json:"product_id" db:"product_id"`
+	AdminID       int       `json:"-" db:"admin_id"`
+	Title         string    `json:"title" db:"title"`
+	Kind          string    `json:"kind" db:"kind"`
+	WorkoutTypeID *int      `json:"workout_type_id" db:"workout_type_id"`
+	StartsOn      time.Time `json:"starts_on" db:"starts_on"`
+	EndsOn        time.Time `json:"ends_on" db:"ends_on"`
+	SessionsLeft  *int      `json:"sessions_left,omitempty" db:"sessions_left"`
+	Price         int       `json:"price" db:"price"`
+	PurchasedAt   time.Time `json:"purchased_at" db:"purchased_at"`
+}
+
+// CancellationPolicy sets the fees for late cancellations and no-shows.
+// Fees are a percentage of the workout price, the trainer gets
+// TrainerSharePercent of each fee.
+type CancellationPolicy struct {
+	FreeCancelMinutes    int       `json:"free_cancel_minutes" db:"free_cancel_minutes"`
+	LateCancelFeePercent int       `json:"late_cancel_fee_percent" db:"late_cancel_fee_percent"`
+	NoShowFeePercent     int       `json:"no_show_fee_percent" db:"no_show_fee_percent"`
+	TrainerSharePercent  int       `json:"trainer_share_percent" db:"trainer_share_percent"`
+	UpdatedAt            time.Time `json:"updated_at" db:"updated_at"`
+}
+
+const (
+	ChargeLateCancel = "late_cancel"
+	ChargeNoShow     = "no_show"
+)
+
+type WorkoutCharge struct {
+	ID            int       `json:"id" db:"id"`
+	WorkoutID     int       `json:"workout_id" db:"workout_id"`
+	Kind          string    `json:"kind" db:"kind"`
+	Amount        int       `json:"amount" db:"amount"`
+	TrainerAmount int       `json:"trainer_amount" db:"trainer_amount"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
 type WorkoutRequest struct {
 	ID            int       `json:"id,omitempty" db:"id"`
 	ClientID      int       `json:"client_id,omitempty" db:"client_id"`
@@ -47,15 +475,148 @@ type WorkoutRequest struct {
 	WorkoutTypeID int       `json:"workout_type_id,omitempty" db:"workout_type_id"`
 	AdminID       int       `json:"admin_id,omitempty" db:"admin_id"`
 	Date          time.Time `json:"date,omitempty" db:"date"`
+	EndsAt        time.Time `json:"-" db:"ends_at"`
+	SeriesID      *int      `json:"-" db:"series_id"`
+	ResourceID    *int      `json:"-" db:"resource_id"`
+}
+
+type ClassSessionRequest struct {
+	ID            int       `json:"id,omitempty" db:"id"`
+	TrainerID     int       `json:"trainer_id,omitempty" db:"trainer_id"`
+	WorkoutTypeID int       `json:"workout_type_id,omitempty" db:"workout_type_id"`
+	AdminID       int       `json:"admin_id,omitempty" db:"admin_id"`
+	Capacity      int       `json:"capacity,omitempty" db:"capacity"`
+	Date          time.Time `json:"date,omitempty" db:"date"`
+	EndsAt        time.Time `json:"-" db:"ends_at"`
+	ResourceID    *int      `json:"-" db:"resource_id"`
+}
+
+type ClassSessionResponse struct {
+	ID      int `json:"id" db:"id"`
+	Trainer struct {
+		ID        int    `json:"id" db:"t_id"`
+		FirstName string `json:"first_name" db:"t_first_name"`
+		LastName  string `json:"last_name" db:"t_last_name"`
+	} `json:"trainer" db:"trainers"`
+
+	WorkoutType struct {
+		ID    int    `json:"id" db:"wt_id"`
+		Title string `json:"title" db:"title"`
+		Price int    `json:"price" db:"price"`
+	} `json:"workout_type" db:"workout_types"`
+	Capacity   int           `json:"capacity" db:"capacity"`
+	Booked     int           `json:"booked" db:"booked"`
+	Status     WorkoutStatus `json:"status" db:"status"`
+	Date       time.Time     `json:"date" db:"date"`
+	EndsAt     time.Time     `json:"ends_at" db:"ends_at"`
+	ResourceID *int          `json:"resource_id,omitempty" db:"resource_id"`
+
+	Attendees []ClassAttendee `json:"attendees,omitempty" db:"-"`
+}
+
+type ClassAttendee struct {
+	ClientID  int           `json:"client_id" db:"client_id"`
+	FirstName string        `json:"first_name" db:"first_name"`
+	LastName  string        `json:"last_name" db:"last_name"`
+	Status    WorkoutStatus `json:"status" db:"status"`
+	BookedAt  time.Time     `json:"booked_at" db:"booked_at"`
+}
+
+type ClassBooking struct {
+	ClassID  int `json:"class_id"`
+	ClientID int `json:"client_id"`
+}
+
+const (
+	WaitlistWaiting  = "waiting"
+	WaitlistPromoted = "promoted"
+	WaitlistLeft     = "left"
+	// WaitlistExpired entries waited for a slot or a class that has started.
+	WaitlistExpired = "expired"
+)
+
+// WaitlistEntry is a client waiting either for a place in the class
+// ClassID or for the slot of TrainerID at Date.
+type WaitlistEntry struct {
+	ID            int        `json:"id" db:"id"`
+	Position      int        `json:"position" db:"position"`
+	ClientID      int        `json:"client_id" db:"client_id"`
+	AdminID       int        `json:"-" db:"admin_id"`
+	ClassID       *int       `json:"class_id,omitempty" db:"class_session_id"`
+	TrainerID     *int       `json:"trainer_id,omitempty" db:"trainer_id"`
+	WorkoutTypeID *int       `json:"workout_type_id,omitempty" db:"workout_type_id"`
+	Date          *time.Time `json:"date,omitempty" db:"date"`
+	Status        string     `json:"status" db:"status"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	// PromotionError tells why the client could not be booked when a
+	// place opened up last time.
+	PromotionError    *string    `json:"promotion_error,omitempty" db:"promotion_error"`
+	PromotionFailedAt *time.Time `json:"promotion_failed_at,omitempty" db:"promotion_failed_at"`
+}
+
+// WorkoutSeries is a weekly or biweekly booking that is materialized
+// into workouts when it is created.
+type WorkoutSeries struct {
+	ID            int        `json:"id" db:"id"`
+	ClientID      int        `json:"client_id" db:"client_id"`
+	TrainerID     int        `json:"trainer_id" db:"trainer_id"`
+	WorkoutTypeID int        `json:"workout_type_id" db:"workout_type_id"`
+	AdminID       int        `json:"admin_id" db:"admin_id"`
+	StartsAt      time.Time  `json:"starts_at" db:"starts_at"`
+	IntervalWeeks int        `json:"interval_weeks" db:"interval_weeks"`
+	Until         *time.Time `json:"until" db:"until"`
+	Count         *int       `json:"count" db:"count"`
+}
+
+const (
+	FrequencyWeekly   = "weekly"
+	FrequencyBiweekly = "biweekly"
+)
+
+type WorkoutSeriesRequest struct {
+	ClientID      int        `json:"client_id"`
+	TrainerID     int        `json:"trainer_id"`
+	WorkoutTypeID int        `json:"workout_type_id"`
+	StartsAt      time.Time  `json:"starts_at"`
+	Frequency     string     `json:"frequency"`
+	Until         *time.Time `json:"until,omitempty"`
+	Count         int        `json:"count,omitempty"`
+}
+
+type WorkoutSeriesResponse struct {
+	ID         int   `json:"id"`
+	WorkoutIDs []int `json:"workout_ids"`
+}
+
+const (
+	SeriesScopeSingle    = "single"
+	SeriesScopeFollowing = "following"
+	SeriesScopeAll       = "all"
+)
+
+// SeriesEdit changes occurrences of a series starting from WorkoutID.
+// A new Date moves the selected occurrence, the others in scope are
+// shifted by the same amount.
+type SeriesEdit struct {
+	WorkoutID     int        `json:"workout_id"`
+	Scope         string     `json:"scope"`
+	TrainerID     int        `json:"trainer_id,omitempty"`
+	WorkoutTypeID int        `json:"workout_type_id,omitempty"`
+	Date          *time.Time `json:"date,omitempty"`
+}
+
+type SeriesCancel struct {
+	WorkoutID int    `json:"workout_id"`
+	Scope     string `json:"scope"`
 }
 
 type WorkoutResponse struct {
 	ID     int `json:"id" db:"id"`
 	Client struct {
-		ID          int    `json:"id" db:"c_id"`
-		FirstName   string `json:"first_name" db:"c_first_name"`
-		LastName    string `json:"last_name" db:"c_last_name"`
-		PhoneNumber string `json:"phone_number" db:"phone_number"`
+		ID          int     `json:"id" db:"c_id"`
+		FirstName   string  `json:"first_name" db:"c_first_name"`
+		LastName    string  `json:"last_name" db:"c_last_name"`
+		PhoneNumber *string `json:"phone_number" db:"phone_number"`
 	} `json:"client" db:"clients"`
 
 	Trainer struct {
@@ -69,6 +630,59 @@ type WorkoutResponse struct {
 		Title string `json:"title" db:"title"`
 		Price int    `json:"price" db:"price"`
 	} `json:"workout_type" db:"workout_types"`
-	Status string    `json:"status" db:"status"`
-	Date   time.Time `json:"date" db:"date"`
+	Status WorkoutStatus `json:"status" db:"status"`
+	Date   time.Time     `json:"date" db:"date"`
+
+	SeriesID      *int                  `json:"series_id,omitempty" db:"series_id"`
+	ResourceID    *int                  `json:"resource_id,omitempty" db:"resource_id"`
+	OriginalDate  *time.Time            `json:"original_date,omitempty" db:"original_date"`
+	StatusHistory []WorkoutStatusChange `json:"status_history,omitempty" db:"-"`
+	Reschedules   []WorkoutReschedule   `json:"reschedules,omitempty" db:"-"`
+	Charge        *WorkoutCharge        `json:"charge,omitempty" db:"-"`
+}
+
+// Ledger entry kinds besides the charge kinds of late cancellations and
+// no-shows.
+const (
+	LedgerWorkout    = "workout"
+	LedgerMembership = "membership"
+	LedgerPayment    = "payment"
+)
+
+const (
+	PaymentCash     = "cash"
+	PaymentCard     = "card"
+	PaymentTransfer = "transfer"
+)
+
+// LedgerEntry is money a client paid or owes. Payments are positive and
+// charges negative, so the entries of a client sum up to their balance.
+type LedgerEntry struct {
+	ID           int       `json:"id" db:"id"`
+	ClientID     int       `json:"client_id" db:"client_id"`
+	AdminID      *int      `json:"-" db:"admin_id"`
+	WorkoutID    *int      `json:"workout_id,omitempty" db:"workout_id"`
+	MembershipID *int      `json:"membership_id,omitempty" db:"membership_id"`
+	Kind         string    `json:"kind" db:"kind"`
+	Method       *string   `json:"method,omitempty" db:"method"`
+	Amount       int       `json:"amount" db:"amount"`
+	Note         *string   `json:"note,omitempty" db:"note"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	// Balance is the balance of the client after the entry.
+	Balance int `json:"balance" db:"balance"`
+}
+
+type PaymentInput struct {
+	ClientID int     `json:"client_id"`
+	Method   string  `json:"method"`
+	Amount   int     `json:"amount"`
+	Note     *string `json:"note"`
+}
+
+type ClientBalance struct {
+	ClientID    int     `json:"client_id" db:"client_id"`
+	FirstName   string  `json:"first_name" db:"first_name"`
+	LastName    string  `json:"last_name" db:"last_name"`
+	PhoneNumber *string `json:"phone_number" db:"phone_number"`
+	Balance     int     `json:"balance" db:"balance"`
 }
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+// SetTrainerWorkingHours replaces the weekly schedule of the trainer.
+func (r *Repository) SetTrainerWorkingHours(ctx context.Context, trainerID int, hours []models.WorkingHours) error {
+	s := r.db.NewSession(nil)
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	_, err = tx.DeleteFrom("trainer_working_hours").
+		Where("trainer_id = ?", trainerID).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(hours) != 0 {
+		stmt := tx.InsertInto("trainer_working_hours").
+			Columns(
+				"trainer_id",
+				"weekday",
+				"starts_at",
+				"ends_at",
+			)
+		for _, h := range hours {
+			h.TrainerID = trainerID
+			stmt.Record(h)
+		}
+
+		_, err = stmt.ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetTrainerWorkingHours(ctx context.Context, trainerID int) ([]models.WorkingHours, error) {
+	s := r.db.NewSession(nil)
+
+	var hours = make([]models.WorkingHours, 0)
+	_, err := s.
+		Select(
+			"id",
+			"trainer_id",
+			"weekday",
+			"to_char(starts_at, 'HH24:MI') as starts_at",
+			"to_char(ends_at, 'HH24:MI') as ends_at",
+		).
+		From("trainer_working_hours").
+		Where("trainer_id = ?", trainerID).
+		OrderAsc("weekday").
+		OrderAsc("starts_at").
+		LoadContext(ctx, &hours)
+	if err != nil {
+		return nil, err
+	}
+	return hours, nil
+}
+
+func (r *Repository) CreateAvailabilityException(ctx context.Context, exception models.AvailabilityException) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.InsertInto("trainer_availability_exceptions").
+		Columns(
+			"trainer_id",
+			"date",
+			"starts_at",
+			"ends_at",
+			"reason",
+		).
+		Record(exception).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) DeleteAvailabilityException(ctx context.Context, id int) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.DeleteFrom("trainer_availability_exceptions").
+		Where("id = ?", id).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetAvailabilityExceptions returns exceptions of the trainer dated
+// between from and to inclusive.
+func (r *Repository) GetAvailabilityExceptions(ctx context.Context, trainerID int, from, to time.Time) ([]models.AvailabilityException, error) {
+	s := r.db.NewSession(nil)
+
+	var exceptions = make([]models.AvailabilityException, 0)
+	_, err := s.
+		Select(
+			"id",
+			"trainer_id",
+			"date",
+			"to_char(starts_at, 'HH24:MI') as starts_at",
+			"to_char(ends_at, 'HH24:MI') as ends_at",
+			"reason",
+		).
+		From("trainer_availability_exceptions").
+		Where("trainer_id = ?", trainerID).
+		Where("date BETWEEN DATE(?) AND DATE(?)", from, to).
+		OrderAsc("date").
+		OrderAsc("starts_at").
+		LoadContext(ctx, &exceptions)
+	if err != nil {
+		return nil, err
+	}
+	return exceptions, nil
+}
+
+// GetTrainerBusyTimes returns when the trainer has non-canceled workouts
+// between from and to.
+func (r *Repository) GetTrainerBusyTimes(ctx context.Context, trainerID int, from, to time.Time) ([]models.TimeSlot, error) {
+	s := r.db.NewSession(nil)
+
+	var busy = make([]models.TimeSlot, 0)
+	_, err := s.
+		Select(
+			"date",
+			"ends_at",
+		).
+		From("workouts").
+		Where("trainer_id = ?", trainerID).
+		Where("status <> 'canceled'").
+		Where("date < ? AND ends_at > ?", to, from).
+		OrderAsc("date").
+		LoadContext(ctx, &busy)
+	if err != nil {
+		return nil, err
+	}
+	return busy, nil
+}
@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+var (
+	ErrClassFull     = errors.New("no free places left")
+	ErrClassClosed   = errors.New("class is not open for booking")
+	ErrAlreadyBooked = errors.New("client is already booked")
+)
+
+func (r *Repository) CreateClassSession(ctx context.Context, class models.ClassSessionRequest) error {
+	s := r.db.NewSession(nil)
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	if err = lockClass(ctx, tx, class); err != nil {
+		return err
+	}
+
+	_, err = tx.InsertInto("class_sessions").
+		Columns(
+			"trainer_id",
+			"workout_type_id",
+			"admin_id",
+			"capacity",
+			"date",
+			"ends_at",
+			"resource_id",
+		).
+		Record(class).
+		ExecContext(ctx)
+	if err != nil {
+		return mapError(err)
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) DeleteClassSession(ctx context.Context, id int) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.DeleteFrom("class_sessions").
+		Where("id = ?", id).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// FindConflictingClasses returns IDs of non-canceled classes of the trainer
+// or in the resource that overlap the given time.
+func (r *Repository) FindConflictingClasses(ctx context.Context, trainerID int, resourceID *int, date, endsAt time.Time, excludeID int) ([]int, error) {
+	s := r.db.NewSession(nil)
+
+	var ids = make([]int, 0)
+	_, err := s.
+		Select("id").
+		From("class_sessions").
+		Where("status <> 'canceled'").
+		Where("id <> ?", excludeID).
+		Where("trainer_id = ? OR resource_id = ?", trainerID, resourceID).
+		Where("date < ? AND ends_at > ?", endsAt, date).
+		OrderAsc("id").
+		LoadContext(ctx, &ids)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// FindClientConflictingClasses returns IDs of non-canceled classes the
+// client is booked into that overlap the given time.
+func (r *Repository) FindClientConflictingClasses(ctx context.Context, clientID int, date, endsAt time.Time, excludeID int) ([]int, error) {
+	s := r.db.NewSession(nil)
+
+	var ids = make([]int, 0)
+	_, err := s.
+		Select("class_sessions.id").
+		From("class_sessions").
+		Join("class_attendees", "class_attendees.class_session_id = class_sessions.id").
+		Where("class_sessions.status <> 'canceled'").
+		Where("class_attendees.status <> 'canceled'").
+		Where("class_attendees.client_id = ?", clientID).
+		Where("class_sessions.id <> ?", excludeID).
+		Where("class_sessions.date < ? AND class_sessions.ends_at > ?", endsAt, date).
+		OrderAsc("class_sessions.id").
+		LoadContext(ctx, &ids)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *Repository) classSessionsQuery(s *dbr.Session) *dbr.SelectStmt {
+	return s.
+		Select(
+			"class_sessions.id",
+			"trainers.id as t_id",
+			"trainers.first_name as t_first_name",
+			"trainers.last_name as t_last_name",
+			"workout_types.id as wt_id",
+			"workout_types.title",
+			"workout_types.price",
+			"class_sessions.capacity",
+			"(SELECT COUNT(*) FROM class_attendees WHERE class_attendees.class_session_id = class_sessions.id AND class_attendees.status <> 'canceled') as booked",
+			"class_sessions.status",
+			"class_sessions.date",
+			"class_sessions.ends_at",
+			"class_sessions.resource_id",
+		).
+		Join("workout_types", "workout_types.id = class_sessions.workout_type_id").
+		Join("trainers", "trainers.id = class_sessions.trainer_id").
+		From("class_sessions")
+}
+
+func (r *Repository) GetClassSessionsByDate(ctx context.Context, date time.Time, trainerID int) ([]models.ClassSessionResponse, error) {
+	s := r.db.NewSession(nil)
+
+	stmt := r.classSessionsQuery(s).
+		Where("DATE(class_sessions.date) = ?", date).
+		OrderAsc("class_sessions.date")
+
+	if trainerID != 0 {
+		stmt.Where("class_sessions.trainer_id = ?", trainerID)
+	}
+
+	var classes = make([]models.ClassSessionResponse, 0)
+	_, err := stmt.LoadContext(ctx, &classes)
+	if err != nil {
+		return nil, err
+	}
+	return classes, nil
+}
+
+func (r *Repository) GetClassSessionsByInterval(ctx context.Context, dateFrom, dateTo time.Time, trainerID int) ([]models.ClassSessionResponse, error) {
+	s := r.db.NewSession(nil)
+
+	stmt := r.classSessionsQuery(s).
+		Where("DATE(class_sessions.date) BETWEEN ? AND ?", dateFrom, dateTo).
+		OrderAsc("class_sessions.date")
+
+	if trainerID != 0 {
+		stmt.Where("class_sessions.trainer_id = ?", trainerID)
+	}
+
+	var classes = make([]models.ClassSessionResponse, 0)
+	_, err := stmt.LoadContext(ctx, &classes)
+	if err != nil {
+		return nil, err
+	}
+	return classes, nil
+}
+
+func (r *Repository) GetClassSessionByID(ctx context.Context, id int) (models.ClassSessionResponse, error) {
+	s := r.db.NewSession(nil)
+
+	var class models.ClassSessionResponse
+	err := r.classSessionsQuery(s).
+		Where("class_sessions.id = ?", id).
+		LoadOneContext(ctx, &class)
+	if err != nil {
+		return models.ClassSessionResponse{}, err
+	}
+	return class, nil
+}
+
+func (r *Repository) GetClassAttendees(ctx context.Context, classID int) ([]models.ClassAttendee, error) {
+	s := r.db.NewSession(nil)
+
+	var attendees = make([]models.ClassAttendee, 0)
+	_, err := s.
+		Select(
+			"class_attendees.client_id",
+			"clients.first_name",
+			"clients.last_name",
+			"class_attendees.status",
+			"class_attendees.booked_at",
+		).
+		Join("clients", "clients.id = class_attendees.client_id").
+		From("class_attendees").
+		Where("class_attendees.class_session_id = ?", classID).
+		OrderAsc("class_attendees.booked_at").
+		LoadContext(ctx, &attendees)
+	if err != nil {
+		return nil, err
+	}
+	return attendees, nil
+}
+
+// BookClass adds the client to the class. The class row is locked so that
+// concurrent bookings cannot exceed its capacity. A canceled booking of the
+// same client is renewed. It returns ErrOverlap when the client is busy at
+// the time of the class.
+func (r *Repository) BookClass(ctx context.Context, classID, clientID int) error {
+	s := r.db.NewSession(nil)
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	var class struct {
+		Capacity int                  `db:"capacity"`
+		Status   models.WorkoutStatus `db:"status"`
+		Date     time.Time            `db:"date"`
+		EndsAt   time.Time            `db:"ends_at"`
+	}
+	err = tx.
+		Select("capacity", "status", "date", "ends_at").
+		From("class_sessions").
+		Where("id = ?", classID).
+		Suffix("FOR UPDATE").
+		LoadOneContext(ctx, &class)
+	if err != nil {
+		return err
+	}
+	if class.Status != models.StatusPending {
+		return ErrClassClosed
+	}
+
+	var booked int
+	err = tx.
+		Select("COUNT(*)").
+		From("class_attendees").
+		Where("class_session_id = ?", classID).
+		Where("status <> 'canceled'").
+		LoadOneContext(ctx, &booked)
+	if err != nil {
+		return err
+	}
+	if booked >= class.Capacity {
+		return ErrClassFull
+	}
+
+	if err = lockClientBooking(ctx, tx, clientID, classID, class.Date, class.EndsAt); err != nil {
+		return err
+	}
+
+	res, err := tx.InsertBySql(`
+		INSERT INTO class_attendees (class_session_id, client_id, booked_at) VALUES (?, ?, ?)
+		ON CONFLICT (class_session_id, client_id) DO UPDATE SET
+			status = 'pending',
+			booked_at = excluded.booked_at,
+			status_changed_at = NULL
+		WHERE class_attendees.status = 'canceled'`,
+		classID, clientID, time.Now(),
+	).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAlreadyBooked
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetClassAttendee(ctx context.Context, classID, clientID int) (models.ClassAttendee, error) {
+	s := r.db.NewSession(nil)
+
+	var attendee models.ClassAttendee
+	err := s.
+		Select(
+			"class_attendees.client_id",
+			"clients.first_name",
+			"clients.last_name",
+			"class_attendees.status",
+			"class_attendees.booked_at",
+		).
+		Join("clients", "clients.id = class_attendees.client_id").
+		From("class_attendees").
+		Where("class_attendees.class_session_id = ?", classID).
+		Where("class_attendees.client_id = ?", clientID).
+		LoadOneContext(ctx, &attendee)
+	if err != nil {
+		return models.ClassAttendee{}, err
+	}
+	return attendee, nil
+}
+
+// ChangeAttendeeStatus moves the booking from one status to another.
+// It returns ErrStatusChanged when the booking is no longer in from.
+func (r *Repository) ChangeAttendeeStatus(ctx context.Context, classID, clientID int, from, to models.WorkoutStatus) error {
+	s := r.db.NewSession(nil)
+
+	res, err := s.Update("class_attendees").
+		Set("status", to).
+		Set("status_changed_at", time.Now()).
+		Where("class_session_id = ?", classID).
+		Where("client_id = ?", clientID).
+		Where("status = ?", from).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+// ChangeClassStatus moves the class from one status to another.
+// It returns ErrStatusChanged when the class is no longer in from.
+func (r *Repository) ChangeClassStatus(ctx context.Context, id int, from, to models.WorkoutStatus) error {
+	s := r.db.NewSession(nil)
+
+	res, err := s.Update("class_sessions").
+		Set("status", to).
+		Set("status_changed_at", time.Now()).
+		Where("id = ?", id).
+		Where("status = ?", from).
+		ExecContext(ctx)
+	if err != nil {
+		return mapError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
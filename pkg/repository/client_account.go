@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+func (r *Repository) GetClientByContact(ctx context.Context, contact string) (models.Client, error) {
+	s := r.db.NewSession(nil)
+
+	var client models.Client
+	err := s.
+		Select(
+			"id",
+			"first_name",
+			"last_name",
+			"surname",
+			"phone_number",
+			"email",
+		).
+		From("clients").
+		Where("phone_number = ? OR email = ?", contact, contact).
+		LoadOneContext(ctx, &client)
+	if err != nil {
+		return models.Client{}, err
+	}
+	return client, nil
+}
+
+// CreateClientLoginCode saves the new code and invalidates the codes sent to
+// the client before, so only the latest code can be used.
+func (r *Repository) CreateClientLoginCode(ctx context.Context, code models.ClientLoginCode) error {
+	s := r.db.NewSession(nil)
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	_, err = tx.Update("client_login_codes").
+		Set("expires_at", time.Now()).
+		Where("client_id = ?", code.ClientID).
+		Where("used_at IS NULL").
+		Where("expires_at > ?", time.Now()).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.InsertInto("client_login_codes").
+		Columns(
+			"client_id",
+			"code_hash",
+			"expires_at",
+		).
+		Record(code).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseClientLoginCode spends a valid code and reports whether there was one.
+func (r *Repository) UseClientLoginCode(ctx context.Context, clientID int, codeHash string) (bool, error) {
+	s := r.db.NewSession(nil)
+
+	now := time.Now()
+	res, err := s.Update("client_login_codes").
+		Set("used_at", now).
+		Where("client_id = ?", clientID).
+		Where("code_hash = ?", codeHash).
+		Where("used_at IS NULL").
+		Where("expires_at > ?", now).
+		ExecContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected != 0, nil
+}
+
+func (r *Repository) GetClientWorkouts(ctx context.Context, clientID int, upcoming bool) ([]models.WorkoutResponse, error) {
+	s := r.db.NewSession(nil)
+
+	stmt := s.
+		Select(
+			"workouts.id",
+			"clients.id as c_id",
+			"clients.first_name as c_first_name",
+			"clients.last_name as c_last_name",
+			"clients.phone_number",
+			"trainers.id as t_id",
+			"trainers.first_name as t_first_name",
+			"trainers.last_name as t_last_name",
+			"workout_types.id as wt_id",
+			"workout_types.title",
+			"workout_types.price",
+			"workouts.status",
+			"workouts.date",
+			"workouts.series_id",
+			"workouts.resource_id",
+			"workouts.original_date",
+		).
+		Join("workout_types", "workout_types.id = workouts.workout_type_id").
+		Join("clients", "clients.id = workouts.client_id").
+		Join("trainers", "trainers.id = workouts.trainer_id").
+		From("workouts").
+		Where("workouts.client_id = ?", clientID)
+
+	if upcoming {
+		stmt.Where("workouts.date >= ?", time.Now()).OrderAsc("workouts.date")
+	} else {
+		stmt.Where("workouts.date < ?", time.Now()).OrderDesc("workouts.date")
+	}
+
+	var workouts = make([]models.WorkoutResponse, 0)
+	_, err := stmt.LoadContext(ctx, &workouts)
+	if err != nil {
+		return nil, err
+	}
+	return workouts, nil
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+// SetCompensationRule replaces the rule for the same trainer and workout type.
+func (r *Repository) SetCompensationRule(ctx context.Context, rule models.CompensationRule) error {
+	s := r.db.NewSession(nil)
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	_, err = tx.DeleteFrom("compensation_rules").
+		Where("trainer_id IS NOT DISTINCT FROM ?", rule.TrainerID).
+		Where("workout_type_id IS NOT DISTINCT FROM ?", rule.WorkoutTypeID).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var ruleID int
+	err = tx.InsertInto("compensation_rules").
+		Columns(
+			"trainer_id",
+			"workout_type_id",
+			"kind",
+			"percent",
+			"amount",
+		).
+		Record(rule).
+		Returning("id").
+		LoadContext(ctx, &ruleID)
+	if err != nil {
+		return err
+	}
+
+	if len(rule.Tiers) != 0 {
+		stmt := tx.InsertInto("compensation_tiers").
+			Columns(
+				"rule_id",
+				"min_sessions",
+				"percent",
+			)
+		for _, tier := range rule.Tiers {
+			tier.RuleID = ruleID
+			stmt.Record(tier)
+		}
+
+		_, err = stmt.ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) DeleteCompensationRule(ctx context.Context, id int) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.DeleteFrom("compensation_rules").
+		Where("id = ?", id).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetCompensationRules returns all rules with their tiers, lowest tier first.
+func (r *Repository) GetCompensationRules(ctx context.Context) ([]models.CompensationRule, error) {
+	s := r.db.NewSession(nil)
+
+	var rules = make([]models.CompensationRule, 0)
+	_, err := s.
+		Select(
+			"id",
+			"trainer_id",
+			"workout_type_id",
+			"kind",
+			"percent",
+			"amount",
+		).
+		From("compensation_rules").
+		OrderAsc("id").
+		LoadContext(ctx, &rules)
+	if err != nil {
+		return nil, err
+	}
+
+	var tiers = make([]models.CompensationTier, 0)
+	_, err = s.
+		Select(
+			"rule_id",
+			"min_sessions",
+			"percent",
+		).
+		From("compensation_tiers").
+		OrderAsc("rule_id").
+		OrderAsc("min_sessions").
+		LoadContext(ctx, &tiers)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rules {
+		for _, tier := range tiers {
+			if tier.RuleID == rules[i].ID {
+				rules[i].Tiers = append(rules[i].Tiers, tier)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// GetSessionLines returns the completed workouts of the trainer dated
+// between the from and to days. Zero trainerID means every trainer.
+func (r *Repository) GetSessionLines(ctx context.Context, trainerID int, from, to time.Time) ([]models.EarningLine, error) {
+	s := r.db.NewSession(nil)
+
+	stmt := s.
+		Select(
+			"workouts.id as workout_id",
+			"workouts.trainer_id",
+			"workouts.workout_type_id",
+			"workout_types.title",
+			"workout_types.price",
+			"workouts.date",
+			"'session' as kind",
+		).
+		From("workouts").
+		Join("workout_types", "workout_types.id = workouts.workout_type_id").
+		Where("workouts.status = ?", models.StatusDone).
+		Where("DATE(workouts.date) BETWEEN ? AND ?", from, to).
+		OrderAsc("workouts.date")
+
+	if trainerID != 0 {
+		stmt.Where("workouts.trainer_id = ?", trainerID)
+	}
+
+	var lines = make([]models.EarningLine, 0)
+	_, err := stmt.LoadContext(ctx, &lines)
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// GetChargeLines returns the trainer's shares of late cancellation and
+// no-show fees for workouts dated between the from and to days.
+func (r *Repository) GetChargeLines(ctx context.Context, trainerID int, from, to time.Time) ([]models.EarningLine, error) {
+	s := r.db.NewSession(nil)
+
+	stmt := s.
+		Select(
+			"workouts.id as workout_id",
+			"workouts.trainer_id",
+			"workouts.workout_type_id",
+			"workout_types.title",
+			"workout_types.price",
+			"workouts.date",
+			"workout_charges.kind",
+			"ROUND(workout_charges.trainer_amount * 100.0 / workout_charges.amount) || '% of ' || workout_charges.amount as rate",
+			"workout_charges.trainer_amount as amount",
+		).
+		From("workout_charges").
+		Join("workouts", "workouts.id = workout_charges.workout_id").
+		Join("workout_types", "workout_types.id = workouts.workout_type_id").
+		Where("workout_charges.trainer_amount > 0").
+		Where("DATE(workouts.date) BETWEEN ? AND ?", from, to).
+		OrderAsc("workouts.date")
+
+	if trainerID != 0 {
+		stmt.Where("workouts.trainer_id = ?", trainerID)
+	}
+
+	var lines = make([]models.EarningLine, 0)
+	_, err := stmt.LoadContext(ctx, &lines)
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+var ledgerColumns = []string{
+	"client_id",
+	"admin_id",
+	"workout_id",
+	"membership_id",
+	"kind",
+	"method",
+	"amount",
+	"note",
+}
+
+func (r *Repository) CreateLedgerEntry(ctx context.Context, entry models.LedgerEntry) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.InsertInto("client_ledger").
+		Columns(ledgerColumns...).
+		Record(entry).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// createLedgerEntry saves the entry together with the change it results from.
+func createLedgerEntry(ctx context.Context, tx *dbr.Tx, entry models.LedgerEntry) error {
+	_, err := tx.InsertInto("client_ledger").
+		Columns(ledgerColumns...).
+		Record(entry).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetClientLedger returns the entries of the client in the order they were
+// made, each with the balance after it.
+func (r *Repository) GetClientLedger(ctx context.Context, clientID int) ([]models.LedgerEntry, error) {
+	s := r.db.NewSession(nil)
+
+	var entries = make([]models.LedgerEntry, 0)
+	_, err := s.
+		Select(
+			"id",
+			"client_id",
+			"admin_id",
+			"workout_id",
+			"membership_id",
+			"kind",
+			"method",
+			"amount",
+			"note",
+			"created_at",
+			"SUM(amount) OVER (ORDER BY created_at, id) as balance",
+		).
+		From("client_ledger").
+		Where("client_id = ?", clientID).
+		OrderAsc("created_at").
+		OrderAsc("id").
+		LoadContext(ctx, &entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetDebtors returns the clients with a negative balance, largest debts first.
+func (r *Repository) GetDebtors(ctx context.Context) ([]models.ClientBalance, error) {
+	s := r.db.NewSession(nil)
+
+	var debtors = make([]models.ClientBalance, 0)
+	_, err := s.
+		Select(
+			"clients.id as client_id",
+			"clients.first_name",
+			"clients.last_name",
+			"clients.phone_number",
+			"SUM(client_ledger.amount) as balance",
+		).
+		From("client_ledger").
+		Join("clients", "clients.id = client_ledger.client_id").
+		GroupBy("clients.id").
+		Having("SUM(client_ledger.amount) < 0").
+		OrderAsc("balance").
+		LoadContext(ctx, &debtors)
+	if err != nil {
+		return nil, err
+	}
+	return debtors, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+func (r *Repository) GetLoginAttempts(ctx context.Context, keys ...string) ([]models.LoginAttempt, error) {
+	s := r.db.NewSession(nil)
+
+	var attempts = make([]models.LoginAttempt, 0)
+	stmt := s.
+		Select(
+			"key",
+			"failures",
+			"locked_until",
+			"last_failure_at",
+		).
+		From("login_attempts").
+		OrderDesc("last_failure_at")
+
+	if len(keys) != 0 {
+		stmt.Where("key IN ?", keys)
+	}
+
+	_, err := stmt.LoadContext(ctx, &attempts)
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// RegisterLoginFailure counts a failed sign-in for the key and returns the number
+// of failures in a row. Failures older than resetAfter are forgotten.
+func (r *Repository) RegisterLoginFailure(ctx context.Context, key string, resetAfter time.Duration) (int, error) {
+	s := r.db.NewSession(nil)
+
+	now := time.Now()
+
+	var failures int
+	_, err := s.SelectBySql(`
+		INSERT INTO login_attempts (key, failures, last_failure_at) VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure_at = excluded.last_failure_at
+		RETURNING failures`,
+		key, now, now.Add(-resetAfter),
+	).LoadContext(ctx, &failures)
+	if err != nil {
+		return 0, err
+	}
+	return failures, nil
+}
+
+func (r *Repository) LockLoginKey(ctx context.Context, key string, until time.Time) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.Update("login_attempts").
+		Set("locked_until", until).
+		Where("key = ?", key).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) ClearLoginAttempts(ctx context.Context, key string) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.DeleteFrom("login_attempts").
+		Where("key = ?", key).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+var ErrNoCredit = errors.New("membership has no credits left")
+
+func (r *Repository) CreateMembershipProduct(ctx context.Context, product models.MembershipProduct) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.InsertInto("membership_products").
+		Columns(
+			"workout_type_id",
+			"title",
+			"kind",
+			"sessions",
+			"validity_days",
+			"price",
+			"active",
+		).
+		Record(product).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) UpdateMembershipProduct(ctx context.Context, product models.MembershipProduct) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.Update("membership_products").
+		Set("workout_type_id", product.WorkoutTypeID).
+		Set("title", product.Title).
+		Set("kind", product.Kind).
+		Set("sessions", product.Sessions).
+		Set("validity_days", product.ValidityDays).
+		Set("price", product.Price).
+		Set("active", product.Active).
+		Where("id = ?", product.ID).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) GetMembershipProductByID(ctx context.Context, id int) (models.MembershipProduct, error) {
+	s := r.db.NewSession(nil)
+
+	var product models.MembershipProduct
+	err := s.
+		Select(
+			"id",
+			"workout_type_id",
+			"title",
+			"kind",
+			"sessions",
+			"validity_days",
+			"price",
+			"active",
+		).
+		From("membership_products").
+		Where("id = ?", id).
+		LoadOneContext(ctx, &product)
+	if err != nil {
+		return models.MembershipProduct{}, err
+	}
+	return product, nil
+}
+
+func (r *Repository) GetMembershipProducts(ctx context.Context) ([]models.MembershipProduct, error) {
+	s := r.db.NewSession(nil)
+
+	var products = make([]models.MembershipProduct, 0)
+	_, err := s.
+		Select(
+			"id",
+			"workout_type_id",
+			"title",
+			"kind",
+			"sessions",
+			"validity_days",
+			"price",
+			"active",
+		).
+		From("membership_products").
+		OrderAsc("id").
+		LoadContext(ctx, &products)
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// HasTypeMembershipProduct reports whether an active product is sold for
+// workouts of the type specifically.
+func (r *Repository) HasTypeMembershipProduct(ctx context.Context, workoutTypeID int) (bool, error) {
+	s := r.db.NewSession(nil)
+
+	var count int
+	err := s.
+		Select("COUNT(*)").
+		From("membership_products").
+		Where("workout_type_id = ?", workoutTypeID).
+		Where("active").
+		LoadOneContext(ctx, &count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CreateClientMembership saves the membership and, when charge is not nil,
+// charges the client for it.
+func (r *Repository) CreateClientMembership(ctx context.Context, membership models.ClientMembership, charge *models.LedgerEntry) error {
+	s := r.db.NewSession(nil)
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	var id int
+	err = tx.InsertInto("client_memberships").
+		Columns(
+			"client_id",
+			"product_id",
+			"admin_id",
+			"starts_on",
+			"ends_on",
+			"sessions_left",
+			"price",
+		).
+		Record(membership).
+		Returning("id").
+		LoadContext(ctx, &id)
+	if err != nil {
+		return err
+	}
+
+	if charge != nil {
+		charge.MembershipID = &id
+		if err := createLedgerEntry(ctx, tx, *charge); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) clientMembershipsQuery(s *dbr.Session) *dbr.SelectStmt {
+	return s.
+		Select(
+			"client_memberships.id",
+			"client_memberships.client_id",
+			"client_memberships.product_id",
+			"client_memberships.admin_id",
+			"membership_products.title",
+			"membership_products.kind",
+			"membership_products.workout_type_id",
+			"client_memberships.starts_on",
+			"client_memberships.ends_on",
+			"client_memberships.sessions_left",
+			"client_memberships.price",
+			"client_memberships.purchased_at",
+		).
+		From("client_memberships").
+		Join("membership_products", "membership_products.id = client_memberships.product_id")
+}
+
+func (r *Repository) GetClientMemberships(ctx context.Context, clientID int) ([]models.ClientMembership, error) {
+	s := r.db.NewSession(nil)
+
+	var memberships = make([]models.ClientMembership, 0)
+	_, err := r.clientMembershipsQuery(s).
+		Where("client_memberships.client_id = ?", clientID).
+		OrderDesc("client_memberships.purchased_at").
+		LoadContext(ctx, &memberships)
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// GetUsableMembership returns the membership of the client that covers a
+// workout of the type on the day of date and still has credits. Of several
+// memberships the one that expires first is used, packages before passes.
+func (r *Repository) GetUsableMembership(ctx context.Context, clientID, workoutTypeID int, date time.Time) (models.ClientMembership, error) {
+	s := r.db.NewSession(nil)
+
+	var membership models.ClientMembership
+	err := r.clientMembershipsQuery(s).
+		Where("client_memberships.client_id = ?", clientID).
+		Where("DATE(?) BETWEEN client_memberships.starts_on AND client_memberships.ends_on", date).
+		Where("client_memberships.sessions_left IS NULL OR client_memberships.sessions_left > 0").
+		Where("membership_products.workout_type_id IS NULL OR membership_products.workout_type_id = ?", workoutTypeID).
+		OrderAsc("client_memberships.ends_on").
+		OrderBy("client_memberships.sessions_left IS NULL").
+		OrderAsc("client_memberships.id").
+		Limit(1).
+		LoadOneContext(ctx, &membership)
+	if err != nil {
+		return models.ClientMembership{}, err
+	}
+	return membership, nil
+}
+
+// useMembershipCredit takes one credit of the membership for the workout.
+// It returns ErrNoCredit when the package was used up in the meantime.
+func useMembershipCredit(ctx context.Context, tx *dbr.Tx, membershipID, workoutID int, at time.Time) error {
+	res, err := tx.Update("client_memberships").
+		Set("sessions_left", dbr.Expr("sessions_left - 1")).
+		Where("id = ?", membershipID).
+		Where("sessions_left IS NULL OR sessions_left > 0").
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNoCredit
+	}
+
+	_, err = tx.InsertInto("membership_usages").
+		Pair("membership_id", membershipID).
+		Pair("workout_id", workoutID).
+		Pair("used_at", at).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+func (r *Repository) CreatePayrollPeriod(ctx context.Context, period models.PayrollPeriod) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.InsertInto("payroll_periods").
+		Columns(
+			"admin_id",
+			"starts_on",
+			"ends_on",
+		).
+		Record(period).
+		ExecContext(ctx)
+	if err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+func (r *Repository) GetPayrollPeriods(ctx context.Context) ([]models.PayrollPeriod, error) {
+	s := r.db.NewSession(nil)
+
+	var periods = make([]models.PayrollPeriod, 0)
+	_, err := s.
+		Select(
+			"id",
+			"admin_id",
+			"starts_on",
+			"ends_on",
+			"status",
+			"created_at",
+			"closed_at",
+		).
+		From("payroll_periods").
+		OrderDesc("starts_on").
+		LoadContext(ctx, &periods)
+	if err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+func (r *Repository) GetPayrollPeriodByID(ctx context.Context, id int) (models.PayrollPeriod, error) {
+	s := r.db.NewSession(nil)
+
+	var period models.PayrollPeriod
+	err := s.
+		Select(
+			"id",
+			"admin_id",
+			"starts_on",
+			"ends_on",
+			"status",
+			"created_at",
+			"closed_at",
+		).
+		From("payroll_periods").
+		Where("id = ?", id).
+		LoadOneContext(ctx, &period)
+	if err != nil {
+		return models.PayrollPeriod{}, err
+	}
+	return period, nil
+}
+
+type payrollLine struct {
+	PeriodID int `db:"period_id"`
+	models.EarningLine
+}
+
+// ClosePayrollPeriod closes the open period and saves its statement lines.
+// It returns ErrStatusChanged when the period is already closed.
+func (r *Repository) ClosePayrollPeriod(ctx context.Context, id int, lines []models.EarningLine) error {
+	s := r.db.NewSession(nil)
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	res, err := tx.Update("payroll_periods").
+		Set("status", models.PayrollClosed).
+		Set("closed_at", time.Now()).
+		Where("id = ?", id).
+		Where("status = ?", models.PayrollOpen).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStatusChanged
+	}
+
+	if len(lines) != 0 {
+		stmt := tx.InsertInto("payroll_lines").
+			Columns(
+				"period_id",
+				"trainer_id",
+				"workout_id",
+				"workout_type_id",
+				"title",
+				"price",
+				"date",
+				"kind",
+				"rate",
+				"amount",
+			)
+		for _, line := range lines {
+			stmt.Record(payrollLine{PeriodID: id, EarningLine: line})
+		}
+		if _, err := stmt.ExecContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetPayrollLines returns the statement lines saved when the period was
+// closed. Zero trainerID means every trainer.
+func (r *Repository) GetPayrollLines(ctx context.Context, periodID, trainerID int) ([]models.EarningLine, error) {
+	s := r.db.NewSession(nil)
+
+	stmt := s.
+		Select(
+			"workout_id",
+			"trainer_id",
+			"workout_type_id",
+			"title",
+			"price",
+			"date",
+			"kind",
+			"rate",
+			"amount",
+		).
+		From("payroll_lines").
+		Where("period_id = ?", periodID).
+		OrderAsc("date").
+		OrderAsc("id")
+
+	if trainerID != 0 {
+		stmt.Where("trainer_id = ?", trainerID)
+	}
+
+	var lines = make([]models.EarningLine, 0)
+	_, err := stmt.LoadContext(ctx, &lines)
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// IsDateInClosedPeriod reports whether the day of date belongs to a closed
+// payroll period.
+func (r *Repository) IsDateInClosedPeriod(ctx context.Context, date time.Time) (bool, error) {
+	s := r.db.NewSession(nil)
+
+	var closed int
+	err := s.
+		Select("COUNT(*)").
+		From("payroll_periods").
+		Where("status = ?", models.PayrollClosed).
+		Where("DATE(?) BETWEEN starts_on AND ends_on", date).
+		LoadOneContext(ctx, &closed)
+	if err != nil {
+		return false, err
+	}
+	return closed != 0, nil
+}
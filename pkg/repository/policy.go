@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+func (r *Repository) GetCancellationPolicy(ctx context.Context) (models.CancellationPolicy, error) {
+	s := r.db.NewSession(nil)
+
+	var policy models.CancellationPolicy
+	err := s.
+		Select(
+			"free_cancel_minutes",
+			"late_cancel_fee_percent",
+			"no_show_fee_percent",
+			"trainer_share_percent",
+			"updated_at",
+		).
+		From("cancellation_policy").
+		LoadOneContext(ctx, &policy)
+	if err != nil {
+		return models.CancellationPolicy{}, err
+	}
+	return policy, nil
+}
+
+func (r *Repository) UpdateCancellationPolicy(ctx context.Context, policy models.CancellationPolicy) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.Update("cancellation_policy").
+		Set("free_cancel_minutes", policy.FreeCancelMinutes).
+		Set("late_cancel_fee_percent", policy.LateCancelFeePercent).
+		Set("no_show_fee_percent", policy.NoShowFeePercent).
+		Set("trainer_share_percent", policy.TrainerSharePercent).
+		Set("updated_at", policy.UpdatedAt).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) GetWorkoutCharge(ctx context.Context, workoutID int) (models.WorkoutCharge, error) {
+	s := r.db.NewSession(nil)
+
+	var charge models.WorkoutCharge
+	err := s.
+		Select(
+			"id",
+			"workout_id",
+			"kind",
+			"amount",
+			"trainer_amount",
+			"created_at",
+		).
+		From("workout_charges").
+		Where("workout_id = ?", workoutID).
+		LoadOneContext(ctx, &charge)
+	if err != nil {
+		return models.WorkoutCharge{}, err
+	}
+	return charge, nil
+}
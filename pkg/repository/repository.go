@@ -2,10 +2,18 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/Hymiside/fitness-api/pkg/models"
 	"github.com/gocraft/dbr/v2"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrOverlap       = errors.New("overlaps with an existing record")
+	ErrDuplicate     = errors.New("record already exists")
+	ErrStatusChanged = errors.New("status was changed concurrently")
 )
 
 type Repository struct {
@@ -16,6 +24,20 @@ func NewRepository(db *dbr.Connection) *Repository {
 	return &Repository{db: db}
 }
 
+// mapError translates postgres constraint errors into repository errors.
+func mapError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23P01":
+			return ErrOverlap
+		case "23505":
+			return ErrDuplicate
+		}
+	}
+	return err
+}
+
 func (r *Repository) GetAdmin(ctx context.Context, login string) (models.Admin, error) {
 	s := r.db.NewSession(nil) 
 
@@ -28,6 +50,8 @@ func (r *Repository) GetAdmin(ctx context.Context, login string) (models.Admin,
 			"first_name", 
 			"last_name",
 			"super",
+			"totp_secret",
+			"totp_enabled",
 		).
 		From("admins").
 		Where("login = ?", login).
@@ -74,6 +98,20 @@ func (r *Repository) CreateAdmin(ctx context.Context, admin models.Admin) error
 	return nil
 }
 
+func (r *Repository) UpdateAdminPassword(ctx context.Context, id int, password string) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.Update("admins").
+		Set("password", password).
+		Where("id = ?", id).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (r *Repository) DeleteAdmin(ctx context.Context, id int) error {
 	s := r.db.NewSession(nil)
 
@@ -98,6 +136,8 @@ func (r *Repository) GetAdmins(ctx context.Context) ([]models.Admin, error) {
 		"first_name",
 		"last_name",
 		"super",
+		"totp_secret",
+		"totp_enabled",
 	).
 		From("admins").
 		LoadContext(ctx, &admins)
@@ -108,23 +148,44 @@ func (r *Repository) GetAdmins(ctx context.Context) ([]models.Admin, error) {
 	return admins, nil
 }
 
-func (r *Repository) CreateTrainer(ctx context.Context, trainer models.Trainer) (string, error) {
+func (r *Repository) CreateTrainer(ctx context.Context, trainer models.Trainer) error {
 	s := r.db.NewSession(nil)
 
-	var token string
-	err := s.InsertInto("trainers").
+	_, err := s.InsertInto("trainers").
 		Columns(
-			"token",
+			"token_hash",
+			"token_expires_at",
 			"first_name",
 			"last_name",
 		).
-		Returning("token").
 		Record(trainer).
-		LoadContext(ctx, &token)
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) UpdateTrainerToken(ctx context.Context, id int, tokenHash string, expiresAt *time.Time) error {
+	s := r.db.NewSession(nil)
+
+	res, err := s.Update("trainers").
+		Set("token_hash", tokenHash).
+		Set("token_expires_at", expiresAt).
+		Where("id = ?", id).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
 	if err != nil {
-		return "", err
+		return err
+	}
+	if affected == 0 {
+		return dbr.ErrNotFound
 	}
-	return token, nil
+	return nil
 }
 
 func (r *Repository) DeleteTrainer(ctx context.Context, id int) error {
@@ -151,6 +212,8 @@ func (r *Repository) GetAdminByID(ctx context.Context, id int) (models.Admin, er
 			"first_name",
 			"last_name",
 			"super",
+			"totp_secret",
+			"totp_enabled",
 		).
 		From("admins").
 		Where("id = ?", id).
@@ -171,6 +234,9 @@ func (r *Repository) GetWorkoutTypeByID(ctx context.Context, id int) (models.Wor
 			"id",
 			"title",
 			"price",
+			"duration",
+			"resource_id",
+			"membership_required",
 		).
 		From("workout_types").
 		Where("id = ?", id).
@@ -182,19 +248,20 @@ func (r *Repository) GetWorkoutTypeByID(ctx context.Context, id int) (models.Wor
 	return workoutType, nil
 }
 
-func (r *Repository) GetTrainerByToken(ctx context.Context, token string) (models.Trainer, error) {
+func (r *Repository) GetTrainerByToken(ctx context.Context, tokenHash string) (models.Trainer, error) {
 	s := r.db.NewSession(nil)
 
 	var trainer models.Trainer
 	err := s.
 		Select(
 			"id",
-			"token",
+			"token_expires_at",
 			"first_name",
 			"last_name",
 		).
 		From("trainers").
-		Where("token = ?", token).
+		Where("token_hash = ?", tokenHash).
+		Where("token_expires_at IS NULL OR token_expires_at > ?", time.Now()).
 		LoadOneContext(ctx, &trainer)
 	if err != nil {
 		return models.Trainer{}, err
@@ -209,7 +276,7 @@ func (r *Repository) GetTrainers(ctx context.Context) ([]models.Trainer, error)
 	_, err := s.
 		Select(
 			"id",
-			"token",
+			"token_expires_at",
 			"first_name",
 			"last_name",
 		).
@@ -228,7 +295,7 @@ func (r *Repository) GetTrainerByID(ctx context.Context, id int) (models.Trainer
 	err := s.
 		Select(
 			"id",
-			"token",
+			"token_expires_at",
 			"first_name",
 			"last_name",
 		).
@@ -249,6 +316,8 @@ func (r *Repository) CreateClient(ctx context.Context, client models.Client) err
 			"first_name",
 			"last_name",
 			"surname",
+			"phone_number",
+			"email",
 		).
 		Record(client).
 		ExecContext(ctx)
@@ -265,6 +334,8 @@ func (r *Repository) UpdateClient(ctx context.Context, client models.Client) err
 		Set("first_name", client.FirstName).
 		Set("last_name", client.LastName).
 		Set("surname", client.Surname).
+		Set("phone_number", client.PhoneNumber).
+		Set("email", client.Email).
 		Where("id = ?", client.ID).
 		ExecContext(ctx)
 	if err != nil {
@@ -273,39 +344,53 @@ func (r *Repository) UpdateClient(ctx context.Context, client models.Client) err
 	return nil
 }
 
-func (r *Repository) GetClients(ctx context.Context) ([]models.Client, error) {
+func (r *Repository) GetClients(ctx context.Context, trainerID int) ([]models.Client, error) {
 	s := r.db.NewSession(nil)
 
 	var clients = make([]models.Client, 0)
-	_, err := s.
+	stmt := s.
 		Select(
 			"id",
 			"first_name",
 			"last_name",
 			"surname",
+			"phone_number",
+			"email",
 		).
-		From("clients").
-		LoadContext(ctx, &clients)
+		From("clients")
+
+	if trainerID != 0 {
+		stmt.Where("id IN (SELECT client_id FROM workouts WHERE trainer_id = ?)", trainerID)
+	}
+
+	_, err := stmt.LoadContext(ctx, &clients)
 	if err != nil {
 		return nil, err
 	}
 	return clients, nil
 }
 
-func (r *Repository) GetClientByID(ctx context.Context, id int) (models.Client, error) {
+func (r *Repository) GetClientByID(ctx context.Context, id, trainerID int) (models.Client, error) {
 	s := r.db.NewSession(nil)
 
 	var client models.Client
-	err := s.
+	stmt := s.
 		Select(
 			"id",
 			"first_name",
 			"last_name",
 			"surname",
+			"phone_number",
+			"email",
 		).
 		From("clients").
-		Where("id = ?", id).
-		LoadOneContext(ctx, &client)
+		Where("id = ?", id)
+
+	if trainerID != 0 {
+		stmt.Where("id IN (SELECT client_id FROM workouts WHERE trainer_id = ?)", trainerID)
+	}
+
+	err := stmt.LoadOneContext(ctx, &client)
 	if err != nil {
 		return models.Client{}, err
 	}
@@ -315,46 +400,105 @@ func (r *Repository) GetClientByID(ctx context.Context, id int) (models.Client,
 func (r *Repository) CreateWorkout(ctx context.Context, workout models.WorkoutRequest) error {
 	s := r.db.NewSession(nil)
 
-	_, err := s.InsertInto("workouts").
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	if err = lockWorkouts(ctx, tx, workout); err != nil {
+		return err
+	}
+
+	_, err = tx.InsertInto("workouts").
 		Columns(
 			"client_id",
 			"trainer_id",
 			"workout_type_id",
 			"admin_id",
+			"date",
+			"ends_at",
+			"resource_id",
 		).
 		Record(workout).
 		ExecContext(ctx)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
-	return nil
+
+	return tx.Commit()
 }
 
 func (r *Repository) UpdateWorkout(ctx context.Context, workout models.WorkoutRequest) error {
 	s := r.db.NewSession(nil)
 
-	_, err := s.Update("workouts").
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	if err = lockWorkouts(ctx, tx, workout); err != nil {
+		return err
+	}
+
+	_, err = tx.Update("workouts").
 		Set("client_id", workout.ClientID).
 		Set("trainer_id", workout.TrainerID).
 		Set("workout_type_id", workout.WorkoutTypeID).
 		Set("date", workout.Date).
+		Set("ends_at", workout.EndsAt).
+		Set("resource_id", workout.ResourceID).
 		Where("id = ?", workout.ID).
 		ExecContext(ctx)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
-	return nil
+
+	return tx.Commit()
 }
 
+// FindConflictingWorkouts returns IDs of non-canceled workouts that share the trainer,
+// the client or the resource with the given one and overlap it in time.
+func (r *Repository) FindConflictingWorkouts(ctx context.Context, workout models.WorkoutRequest) ([]int, error) {
+	s := r.db.NewSession(nil)
+
+	var ids = make([]int, 0)
+	_, err := s.
+		Select("id").
+		From("workouts").
+		Where("status <> 'canceled'").
+		Where("id <> ?", workout.ID).
+		Where("trainer_id = ? OR client_id = ? OR resource_id = ?", workout.TrainerID, workout.ClientID, workout.ResourceID).
+		Where("date < ? AND ends_at > ?", workout.EndsAt, workout.Date).
+		OrderAsc("id").
+		LoadContext(ctx, &ids)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// DeleteWorkout removes a pending workout. It returns ErrStatusChanged
+// when the workout is no longer pending.
 func (r *Repository) DeleteWorkout(ctx context.Context, id int) error {
 	s := r.db.NewSession(nil)
 
-	_, err := s.DeleteFrom("workouts").
+	res, err := s.DeleteFrom("workouts").
 		Where("id = ?", id).
+		Where("status = ?", models.StatusPending).
 		ExecContext(ctx)
 	if err != nil {
 		return err
 	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStatusChanged
+	}
 	return nil
 }
 
@@ -365,6 +509,9 @@ func (r *Repository) CreateWorkoutType(ctx context.Context, workoutType models.W
 		Columns(
 			"title",
 			"price",
+			"duration",
+			"resource_id",
+			"membership_required",
 		).
 		Record(workoutType).
 		ExecContext(ctx)
@@ -380,6 +527,9 @@ func (r *Repository) UpdateWorkoutType(ctx context.Context, workoutType models.W
 	_, err := s.Update("workout_types").
 		Set("title", workoutType.Title).
 		Set("price", workoutType.Price).
+		Set("duration", workoutType.Duration).
+		Set("resource_id", workoutType.ResourceID).
+		Set("membership_required", workoutType.MembershipRequired).
 		Where("id = ?", workoutType.ID).
 		ExecContext(ctx)
 	if err != nil {
@@ -409,6 +559,9 @@ func (r *Repository) GetWorkoutTypes(ctx context.Context) ([]models.WorkoutType,
 			"id",
 			"title",
 			"price",
+			"duration",
+			"resource_id",
+			"membership_required",
 		).
 		From("workout_types").
 		LoadContext(ctx, &workoutTypes)
@@ -437,6 +590,9 @@ func (r *Repository) GetWorkoutsByDate(ctx context.Context, date time.Time, trai
 			"workout_types.price",
 			"workouts.status",
 			"workouts.date",
+			"workouts.series_id",
+			"workouts.resource_id",
+			"workouts.original_date",
 		).
 		Join("workout_types", "workout_types.id = workouts.workout_type_id").
 		Join("clients", "clients.id = workouts.client_id").
@@ -474,6 +630,9 @@ func (r *Repository) GetWorkoutsByInterval(ctx context.Context, dateFrom, dateTo
 			"workout_types.price",
 			"workouts.status",
 			"workouts.date",
+			"workouts.series_id",
+			"workouts.resource_id",
+			"workouts.original_date",
 		).
 		Join("workout_types", "workout_types.id = workouts.workout_type_id").
 		Join("clients", "clients.id = workouts.client_id").
@@ -511,6 +670,9 @@ func (r *Repository) GetWorkoutByID(ctx context.Context, id int) (models.Workout
 			"workout_types.price",
 			"workouts.status",
 			"workouts.date",
+			"workouts.series_id",
+			"workouts.resource_id",
+			"workouts.original_date",
 		).
 		Join("workout_types", "workout_types.id = workouts.workout_type_id").
 		Join("clients", "clients.id = workouts.client_id").
@@ -542,6 +704,9 @@ func (r *Repository) GetWorkouts(ctx context.Context, trainerID, clientID int) (
 			"workout_types.price",
 			"workouts.status",
 			"workouts.date",
+			"workouts.series_id",
+			"workouts.resource_id",
+			"workouts.original_date",
 		).
 		Join("workout_types", "workout_types.id = workouts.workout_type_id").
 		Join("clients", "clients.id = workouts.client_id").
@@ -563,60 +728,103 @@ func (r *Repository) GetWorkouts(ctx context.Context, trainerID, clientID int) (
 	return workouts, nil
 }
 
-func (r *Repository) ChangeStatusWorkout(ctx context.Context, id int, status string) error {
+// ChangeStatusWorkout moves the workout from change.FromStatus to change.ToStatus
+// and records the transition. It returns ErrStatusChanged when the workout
+// is no longer in change.FromStatus.
+func (r *Repository) ChangeStatusWorkout(ctx context.Context, change models.WorkoutStatusChange) error {
 	s := r.db.NewSession(nil)
 
-	_, err := s.
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	res, err := tx.
 		Update("workouts").
-		Set("status", status).
-		Where("id = ?", id).
+		Set("status", change.ToStatus).
+		Set("status_changed_at", change.ChangedAt).
+		Where("id = ?", change.WorkoutID).
+		Where("status = ?", change.FromStatus).
 		ExecContext(ctx)
+	if err != nil {
+		return mapError(err)
+	}
+
+	affected, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if affected == 0 {
+		return ErrStatusChanged
+	}
 
-	return nil
-}
+	_, err = tx.InsertInto("workout_status_changes").
+		Columns(
+			"workout_id",
+			"from_status",
+			"to_status",
+			"actor_id",
+			"actor_role",
+			"changed_at",
+		).
+		Record(change).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
 
-func (r *Repository) GetCashByMonth(ctx context.Context, trainerID int) (int, error) {
-	s := r.db.NewSession(nil)
+	if change.MembershipID != nil {
+		if err := useMembershipCredit(ctx, tx, *change.MembershipID, change.WorkoutID, change.ChangedAt); err != nil {
+			return err
+		}
+	}
 
-	now := time.Now()
-	firstDayOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
-	lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
+	if change.Charge != nil {
+		_, err = tx.InsertInto("workout_charges").
+			Columns(
+				"workout_id",
+				"kind",
+				"amount",
+				"trainer_amount",
+				"created_at",
+			).
+			Record(change.Charge).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
 
-	var trainerCash dbr.NullInt64
-	err := s.
-		Select("SUM(price)/2").
-		From("workout_types").
-		Join("workouts", "workout_types.id = workouts.workout_type_id").
-		Where("workouts.trainer_id = ?", trainerID).
-		Where("DATE(date) BETWEEN ? AND ?", firstDayOfMonth, lastDayOfMonth).
-		LoadOneContext(ctx, &trainerCash)
-	if err != nil {
-		return 0, err
+	if change.Ledger != nil {
+		if err := createLedgerEntry(ctx, tx, *change.Ledger); err != nil {
+			return err
+		}
 	}
 
-	return int(trainerCash.Int64), nil
+	return tx.Commit()
 }
 
-func (r *Repository) GetCashByDay(ctx context.Context, trainerID int) (int, error) {
+func (r *Repository) GetWorkoutStatusHistory(ctx context.Context, workoutID int) ([]models.WorkoutStatusChange, error) {
 	s := r.db.NewSession(nil)
 
-	now := time.Now()
-	day := now.Format("2006-01-02")
-
-	var trainerCash dbr.NullInt64
-	err := s.
-		Select("COALESCE(SUM(price)/2, 0)").
-		From("workout_types").
-		Join("workouts", "workout_types.id = workouts.workout_type_id").
-		Where("workouts.trainer_id = ?", trainerID).
-		Where("DATE(workouts.date) = ?", day).
-		LoadOneContext(ctx, &trainerCash)
+	var changes = make([]models.WorkoutStatusChange, 0)
+	_, err := s.
+		Select(
+			"id",
+			"workout_id",
+			"from_status",
+			"to_status",
+			"actor_id",
+			"actor_role",
+			"changed_at",
+		).
+		From("workout_status_changes").
+		Where("workout_id = ?", workoutID).
+		OrderAsc("changed_at").
+		LoadContext(ctx, &changes)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-
-	return int(trainerCash.Int64), nil
+	return changes, nil
 }
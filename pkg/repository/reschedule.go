@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+// RescheduleWorkout moves a pending workout to workout.Date and records the
+// move. The first date of the workout is kept in original_date. It returns
+// ErrStatusChanged when the workout is no longer pending.
+func (r *Repository) RescheduleWorkout(ctx context.Context, workout models.WorkoutRequest, move models.WorkoutReschedule) error {
+	s := r.db.NewSession(nil)
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	if err = lockWorkouts(ctx, tx, workout); err != nil {
+		return err
+	}
+
+	res, err := tx.Update("workouts").
+		Set("original_date", dbr.Expr("COALESCE(original_date, date)")).
+		Set("date", workout.Date).
+		Set("ends_at", workout.EndsAt).
+		Set("resource_id", workout.ResourceID).
+		Where("id = ?", workout.ID).
+		Where("status = ?", models.StatusPending).
+		ExecContext(ctx)
+	if err != nil {
+		return mapError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStatusChanged
+	}
+
+	_, err = tx.InsertInto("workout_reschedules").
+		Columns(
+			"workout_id",
+			"from_date",
+			"to_date",
+			"actor_id",
+			"actor_role",
+			"reason",
+			"moved_at",
+		).
+		Record(move).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetWorkoutReschedules(ctx context.Context, workoutID int) ([]models.WorkoutReschedule, error) {
+	s := r.db.NewSession(nil)
+
+	var moves = make([]models.WorkoutReschedule, 0)
+	_, err := s.
+		Select(
+			"id",
+			"workout_id",
+			"from_date",
+			"to_date",
+			"actor_id",
+			"actor_role",
+			"reason",
+			"moved_at",
+		).
+		From("workout_reschedules").
+		Where("workout_id = ?", workoutID).
+		OrderAsc("moved_at").
+		LoadContext(ctx, &moves)
+	if err != nil {
+		return nil, err
+	}
+	return moves, nil
+}
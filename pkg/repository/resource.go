@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+func (r *Repository) CreateResource(ctx context.Context, resource models.Resource) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.InsertInto("resources").
+		Columns(
+			"title",
+			"kind",
+			"capacity",
+		).
+		Record(resource).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) UpdateResource(ctx context.Context, resource models.Resource) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.Update("resources").
+		Set("title", resource.Title).
+		Set("kind", resource.Kind).
+		Set("capacity", resource.Capacity).
+		Where("id = ?", resource.ID).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) DeleteResource(ctx context.Context, id int) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.DeleteFrom("resources").
+		Where("id = ?", id).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) GetResourceByID(ctx context.Context, id int) (models.Resource, error) {
+	s := r.db.NewSession(nil)
+
+	var resource models.Resource
+	err := s.
+		Select(
+			"id",
+			"title",
+			"kind",
+			"capacity",
+		).
+		From("resources").
+		Where("id = ?", id).
+		LoadOneContext(ctx, &resource)
+	if err != nil {
+		return models.Resource{}, err
+	}
+	return resource, nil
+}
+
+func (r *Repository) GetResources(ctx context.Context) ([]models.Resource, error) {
+	s := r.db.NewSession(nil)
+
+	var resources = make([]models.Resource, 0)
+	_, err := s.
+		Select(
+			"id",
+			"title",
+			"kind",
+			"capacity",
+		).
+		From("resources").
+		OrderAsc("id").
+		LoadContext(ctx, &resources)
+	if err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+// Workouts and classes share trainers, clients and resources, but the
+// exclusion constraints only see their own table. Writes that can overlap
+// across the tables take an advisory lock per trainer, client and resource
+// and check the other table while holding it.
+const (
+	lockTrainer = iota + 1
+	lockClient
+	lockResource
+)
+
+type scheduleLock struct {
+	kind int
+	id   int
+}
+
+// lockSchedules takes the locks in a fixed order, so that two transactions
+// never wait for each other. They are released when tx ends.
+func lockSchedules(ctx context.Context, tx *dbr.Tx, locks []scheduleLock) error {
+	sort.Slice(locks, func(i, j int) bool {
+		if locks[i].kind != locks[j].kind {
+			return locks[i].kind < locks[j].kind
+		}
+		return locks[i].id < locks[j].id
+	})
+
+	for _, lock := range locks {
+		if lock.id == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?, ?)", lock.kind, lock.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func workoutLocks(workout models.WorkoutRequest) []scheduleLock {
+	locks := []scheduleLock{{lockTrainer, workout.TrainerID}, {lockClient, workout.ClientID}}
+	if workout.ResourceID != nil {
+		locks = append(locks, scheduleLock{lockResource, *workout.ResourceID})
+	}
+	return locks
+}
+
+// checkWorkoutClasses returns ErrOverlap when a non-canceled class overlaps
+// the workout and has its trainer or resource or is booked by its client.
+func checkWorkoutClasses(ctx context.Context, tx *dbr.Tx, workout models.WorkoutRequest) error {
+	var count int
+	err := tx.
+		Select("COUNT(*)").
+		From("class_sessions").
+		Where("status <> 'canceled'").
+		Where("date < ? AND ends_at > ?", workout.EndsAt, workout.Date).
+		Where(`trainer_id = ? OR resource_id = ? OR id IN (
+			SELECT class_session_id FROM class_attendees WHERE client_id = ? AND status <> 'canceled')`,
+			workout.TrainerID, workout.ResourceID, workout.ClientID).
+		LoadOneContext(ctx, &count)
+	if err != nil {
+		return err
+	}
+	if count != 0 {
+		return ErrOverlap
+	}
+	return nil
+}
+
+// lockWorkouts locks the schedules of the workouts and checks them against
+// classes.
+func lockWorkouts(ctx context.Context, tx *dbr.Tx, workouts ...models.WorkoutRequest) error {
+	var locks []scheduleLock
+	for _, workout := range workouts {
+		locks = append(locks, workoutLocks(workout)...)
+	}
+	if err := lockSchedules(ctx, tx, locks); err != nil {
+		return err
+	}
+
+	for _, workout := range workouts {
+		if err := checkWorkoutClasses(ctx, tx, workout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockClass locks the schedules of the trainer and the resource of the class
+// and returns ErrOverlap when one of them has a workout at the time.
+func lockClass(ctx context.Context, tx *dbr.Tx, class models.ClassSessionRequest) error {
+	locks := []scheduleLock{{lockTrainer, class.TrainerID}}
+	if class.ResourceID != nil {
+		locks = append(locks, scheduleLock{lockResource, *class.ResourceID})
+	}
+	if err := lockSchedules(ctx, tx, locks); err != nil {
+		return err
+	}
+
+	var count int
+	err := tx.
+		Select("COUNT(*)").
+		From("workouts").
+		Where("status <> 'canceled'").
+		Where("date < ? AND ends_at > ?", class.EndsAt, class.Date).
+		Where("trainer_id = ? OR resource_id = ?", class.TrainerID, class.ResourceID).
+		LoadOneContext(ctx, &count)
+	if err != nil {
+		return err
+	}
+	if count != 0 {
+		return ErrOverlap
+	}
+	return nil
+}
+
+// lockClientBooking locks the schedule of the client and returns ErrOverlap
+// when the client has a workout or another class between date and endsAt.
+func lockClientBooking(ctx context.Context, tx *dbr.Tx, clientID, classID int, date, endsAt time.Time) error {
+	if err := lockSchedules(ctx, tx, []scheduleLock{{lockClient, clientID}}); err != nil {
+		return err
+	}
+
+	var workouts int
+	err := tx.
+		Select("COUNT(*)").
+		From("workouts").
+		Where("status <> 'canceled'").
+		Where("client_id = ?", clientID).
+		Where("date < ? AND ends_at > ?", endsAt, date).
+		LoadOneContext(ctx, &workouts)
+	if err != nil {
+		return err
+	}
+
+	var classes int
+	err = tx.
+		Select("COUNT(*)").
+		From("class_sessions").
+		Join("class_attendees", "class_attendees.class_session_id = class_sessions.id").
+		Where("class_sessions.status <> 'canceled'").
+		Where("class_attendees.status <> 'canceled'").
+		Where("class_attendees.client_id = ?", clientID).
+		Where("class_sessions.id <> ?", classID).
+		Where("class_sessions.date < ? AND class_sessions.ends_at > ?", endsAt, date).
+		LoadOneContext(ctx, &classes)
+	if err != nil {
+		return err
+	}
+
+	if workouts != 0 || classes != 0 {
+		return ErrOverlap
+	}
+	return nil
+}
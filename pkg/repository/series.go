@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+// CreateWorkoutSeries stores the series together with its occurrences
+// and returns the series ID and the IDs of the created workouts.
+func (r *Repository) CreateWorkoutSeries(ctx context.Context, series models.WorkoutSeries, workouts []models.WorkoutRequest) (int, []int, error) {
+	s := r.db.NewSession(nil)
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	if err = lockWorkouts(ctx, tx, workouts...); err != nil {
+		return 0, nil, err
+	}
+
+	var seriesID int
+	err = tx.InsertInto("workout_series").
+		Columns(
+			"client_id",
+			"trainer_id",
+			"workout_type_id",
+			"admin_id",
+			"starts_at",
+			"interval_weeks",
+			"until",
+			"count",
+		).
+		Record(series).
+		Returning("id").
+		LoadContext(ctx, &seriesID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	stmt := tx.InsertInto("workouts").
+		Columns(
+			"client_id",
+			"trainer_id",
+			"workout_type_id",
+			"admin_id",
+			"date",
+			"ends_at",
+			"series_id",
+			"resource_id",
+		)
+	for _, workout := range workouts {
+		workout.SeriesID = &seriesID
+		stmt.Record(workout)
+	}
+
+	var ids = make([]int, 0, len(workouts))
+	err = stmt.Returning("id").LoadContext(ctx, &ids)
+	if err != nil {
+		return 0, nil, mapError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	return seriesID, ids, nil
+}
+
+func (r *Repository) GetWorkoutRequestByID(ctx context.Context, id int) (models.WorkoutRequest, error) {
+	s := r.db.NewSession(nil)
+
+	var workout models.WorkoutRequest
+	err := s.
+		Select(
+			"id",
+			"client_id",
+			"trainer_id",
+			"workout_type_id",
+			"admin_id",
+			"date",
+			"ends_at",
+			"series_id",
+		).
+		From("workouts").
+		Where("id = ?", id).
+		LoadOneContext(ctx, &workout)
+	if err != nil {
+		return models.WorkoutRequest{}, err
+	}
+	return workout, nil
+}
+
+// GetPendingSeriesWorkouts returns pending occurrences of the series
+// that start at or after from, earliest first.
+func (r *Repository) GetPendingSeriesWorkouts(ctx context.Context, seriesID int, from time.Time) ([]models.WorkoutRequest, error) {
+	s := r.db.NewSession(nil)
+
+	var workouts = make([]models.WorkoutRequest, 0)
+	_, err := s.
+		Select(
+			"id",
+			"client_id",
+			"trainer_id",
+			"workout_type_id",
+			"admin_id",
+			"date",
+			"ends_at",
+			"series_id",
+		).
+		From("workouts").
+		Where("series_id = ?", seriesID).
+		Where("status = ?", models.StatusPending).
+		Where("date >= ?", from).
+		OrderAsc("date").
+		LoadContext(ctx, &workouts)
+	if err != nil {
+		return nil, err
+	}
+	return workouts, nil
+}
+
+// UpdateWorkouts saves several workouts at once, either all or none of them.
+func (r *Repository) UpdateWorkouts(ctx context.Context, workouts []models.WorkoutRequest) error {
+	s := r.db.NewSession(nil)
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	if err = lockWorkouts(ctx, tx, workouts...); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "SET CONSTRAINTS workouts_trainer_no_overlap, workouts_client_no_overlap, workouts_resource_no_overlap DEFERRED")
+	if err != nil {
+		return err
+	}
+
+	for _, workout := range workouts {
+		_, err := tx.Update("workouts").
+			Set("trainer_id", workout.TrainerID).
+			Set("workout_type_id", workout.WorkoutTypeID).
+			Set("date", workout.Date).
+			Set("ends_at", workout.EndsAt).
+			Set("resource_id", workout.ResourceID).
+			Where("id = ?", workout.ID).
+			ExecContext(ctx)
+		if err != nil {
+			return mapError(err)
+		}
+	}
+
+	return mapError(tx.Commit())
+}
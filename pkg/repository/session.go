@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+func (r *Repository) CreateSession(ctx context.Context, session models.Session) (int, error) {
+	s := r.db.NewSession(nil)
+
+	var id int
+	err := s.InsertInto("sessions").
+		Columns(
+			"user_id",
+			"role",
+			"refresh_token_hash",
+			"expires_at",
+		).
+		Record(session).
+		Returning("id").
+		LoadContext(ctx, &id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *Repository) GetSessionByID(ctx context.Context, id int) (models.Session, error) {
+	s := r.db.NewSession(nil)
+
+	var session models.Session
+	err := s.
+		Select(
+			"id",
+			"user_id",
+			"role",
+			"refresh_token_hash",
+			"expires_at",
+			"revoked_at",
+		).
+		From("sessions").
+		Where("id = ?", id).
+		LoadOneContext(ctx, &session)
+	if err != nil {
+		return models.Session{}, err
+	}
+	return session, nil
+}
+
+func (r *Repository) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (models.Session, error) {
+	s := r.db.NewSession(nil)
+
+	var session models.Session
+	err := s.
+		Select(
+			"id",
+			"user_id",
+			"role",
+			"refresh_token_hash",
+			"expires_at",
+			"revoked_at",
+		).
+		From("sessions").
+		Where("refresh_token_hash = ?", refreshTokenHash).
+		LoadOneContext(ctx, &session)
+	if err != nil {
+		return models.Session{}, err
+	}
+	return session, nil
+}
+
+// RotateSessionToken replaces the refresh token of an active session.
+// It reports false when the old token was already used or the session was revoked.
+func (r *Repository) RotateSessionToken(ctx context.Context, id int, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	s := r.db.NewSession(nil)
+
+	res, err := s.Update("sessions").
+		Set("refresh_token_hash", newHash).
+		Set("expires_at", expiresAt).
+		Where("id = ?", id).
+		Where("refresh_token_hash = ?", oldHash).
+		Where("revoked_at IS NULL").
+		ExecContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *Repository) RevokeSession(ctx context.Context, refreshTokenHash string) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.Update("sessions").
+		Set("revoked_at", time.Now()).
+		Where("refresh_token_hash = ?", refreshTokenHash).
+		Where("revoked_at IS NULL").
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) RevokeUserSessions(ctx context.Context, userID int, roles ...string) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.Update("sessions").
+		Set("revoked_at", time.Now()).
+		Where("user_id = ?", userID).
+		Where("role IN ?", roles).
+		Where("revoked_at IS NULL").
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
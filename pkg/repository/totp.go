@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+func (r *Repository) SetAdminTOTPSecret(ctx context.Context, adminID int, secret string) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.Update("admins").
+		Set("totp_secret", secret).
+		Set("totp_enabled", false).
+		Where("id = ?", adminID).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// EnableAdminTOTP turns the second factor on and replaces the admin's recovery codes.
+func (r *Repository) EnableAdminTOTP(ctx context.Context, adminID int, recoveryCodeHashes []string) error {
+	s := r.db.NewSession(nil)
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	_, err = tx.Update("admins").
+		Set("totp_enabled", true).
+		Where("id = ?", adminID).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.DeleteFrom("admin_recovery_codes").
+		Where("admin_id = ?", adminID).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	stmt := tx.InsertInto("admin_recovery_codes").Columns("admin_id", "code_hash")
+	for _, hash := range recoveryCodeHashes {
+		stmt.Values(adminID, hash)
+	}
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) DisableAdminTOTP(ctx context.Context, adminID int) error {
+	s := r.db.NewSession(nil)
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	_, err = tx.Update("admins").
+		Set("totp_secret", nil).
+		Set("totp_enabled", false).
+		Where("id = ?", adminID).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.DeleteFrom("admin_recovery_codes").
+		Where("admin_id = ?", adminID).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseRecoveryCode marks an unused recovery code as spent and reports whether it was valid.
+func (r *Repository) UseRecoveryCode(ctx context.Context, adminID int, codeHash string) (bool, error) {
+	s := r.db.NewSession(nil)
+
+	res, err := s.Update("admin_recovery_codes").
+		Set("used_at", time.Now()).
+		Where("admin_id = ?", adminID).
+		Where("code_hash = ?", codeHash).
+		Where("used_at IS NULL").
+		ExecContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected != 0, nil
+}
+
+// UseTOTPStep records the time-step of an accepted TOTP code and reports
+// whether it is newer than the last one used by the admin.
+func (r *Repository) UseTOTPStep(ctx context.Context, adminID int, step int64) (bool, error) {
+	s := r.db.NewSession(nil)
+
+	res, err := s.Update("admins").
+		Set("totp_last_step", step).
+		Where("id = ?", adminID).
+		Where("totp_last_step IS NULL OR totp_last_step < ?", step).
+		ExecContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected != 0, nil
+}
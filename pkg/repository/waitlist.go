@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+func (r *Repository) CreateWaitlistEntry(ctx context.Context, entry models.WaitlistEntry) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.InsertInto("waitlist_entries").
+		Columns(
+			"client_id",
+			"admin_id",
+			"class_session_id",
+			"trainer_id",
+			"workout_type_id",
+			"date",
+		).
+		Record(entry).
+		ExecContext(ctx)
+	if err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+// waitlistQuery selects waiting entries numbered in the order they joined.
+func (r *Repository) waitlistQuery(s *dbr.Session) *dbr.SelectStmt {
+	return s.
+		Select(
+			"id",
+			"row_number() OVER (ORDER BY created_at, id) as position",
+			"client_id",
+			"admin_id",
+			"class_session_id",
+			"trainer_id",
+			"workout_type_id",
+			"date",
+			"status",
+			"created_at",
+			"promotion_error",
+			"promotion_failed_at",
+		).
+		From("waitlist_entries").
+		Where("status = ?", models.WaitlistWaiting).
+		OrderAsc("created_at").
+		OrderAsc("id")
+}
+
+func (r *Repository) GetClassWaitlist(ctx context.Context, classID int) ([]models.WaitlistEntry, error) {
+	s := r.db.NewSession(nil)
+
+	var entries = make([]models.WaitlistEntry, 0)
+	_, err := r.waitlistQuery(s).
+		Where("class_session_id = ?", classID).
+		Where("class_session_id IN (SELECT id FROM class_sessions WHERE date > ?)", time.Now()).
+		LoadContext(ctx, &entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetSlotWaitlist returns the entries waiting for a workout with the
+// trainer that would overlap the time from from to to and has not started.
+func (r *Repository) GetSlotWaitlist(ctx context.Context, trainerID int, from, to time.Time) ([]models.WaitlistEntry, error) {
+	s := r.db.NewSession(nil)
+
+	var entries = make([]models.WaitlistEntry, 0)
+	_, err := r.waitlistQuery(s).
+		Where("trainer_id = ?", trainerID).
+		Where("date > ?", time.Now()).
+		Where("date < ?", to).
+		Where("date + make_interval(mins => (SELECT duration FROM workout_types WHERE workout_types.id = waitlist_entries.workout_type_id)) > ?", from).
+		LoadContext(ctx, &entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ExpireWaitlist expires the waiting entries for slots and classes that
+// have started by now.
+func (r *Repository) ExpireWaitlist(ctx context.Context, now time.Time) error {
+	s := r.db.NewSession(nil)
+
+	_, err := s.Update("waitlist_entries").
+		Set("status", models.WaitlistExpired).
+		Set("status_changed_at", now).
+		Where("status = ?", models.WaitlistWaiting).
+		Where("date <= ? OR class_session_id IN (SELECT id FROM class_sessions WHERE date <= ?)", now, now).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ChangeWaitlistStatus moves a waitlist entry from one status to another.
+// It returns ErrStatusChanged when the entry is no longer in from.
+func (r *Repository) ChangeWaitlistStatus(ctx context.Context, id int, from, to string) error {
+	s := r.db.NewSession(nil)
+
+	res, err := s.Update("waitlist_entries").
+		Set("status", to).
+		Set("status_changed_at", time.Now()).
+		Where("id = ?", id).
+		Where("status = ?", from).
+		ExecContext(ctx)
+	if err != nil {
+		return mapError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+// ReturnWaitlistEntry puts a promoted entry back in line at its old place
+// and records why it could not be booked.
+func (r *Repository) ReturnWaitlistEntry(ctx context.Context, id int, reason string) error {
+	s := r.db.NewSession(nil)
+
+	now := time.Now()
+	res, err := s.Update("waitlist_entries").
+		Set("status", models.WaitlistWaiting).
+		Set("status_changed_at", now).
+		Set("promotion_error", reason).
+		Set("promotion_failed_at", now).
+		Where("id = ?", id).
+		Where("status = ?", models.WaitlistPromoted).
+		ExecContext(ctx)
+	if err != nil {
+		return mapError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
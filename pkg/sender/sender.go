@@ -0,0 +1,23 @@
+package sender
+
+import (
+	"context"
+	"log"
+)
+
+// Sender delivers short text messages, such as one-time sign-in codes, to a phone number or an email.
+type Sender interface {
+	Send(ctx context.Context, to, message string) error
+}
+
+// LogSender only writes messages to the log. It is meant for local development.
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, to, message string) error {
+	log.Printf("message to %s: %s", to, message)
+	return nil
+}
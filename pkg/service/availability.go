@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+var (
+	ErrTrainerUnavailable = errors.New("trainer does not work at this time")
+	ErrInvalidHours       = errors.New("working hours must be HH:MM with the start before the end")
+	ErrInvalidRange       = errors.New("invalid date range")
+)
+
+const (
+	// slotStep is how far apart the offered start times are.
+	slotStep = 30 * time.Minute
+	// maxSlotDays limits how many days one slots request may cover.
+	maxSlotDays = 31
+)
+
+func (s *Service) SetTrainerWorkingHours(ctx context.Context, input models.WorkingHoursInput) error {
+	if _, err := s.repos.GetTrainerByID(ctx, input.TrainerID); err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrTrainerNotFound
+		}
+		return err
+	}
+
+	for _, h := range input.Hours {
+		if h.Weekday < 0 || h.Weekday > 6 {
+			return ErrInvalidHours
+		}
+		if err := validateHours(&h.StartsAt, &h.EndsAt); err != nil {
+			return err
+		}
+	}
+
+	return s.repos.SetTrainerWorkingHours(ctx, input.TrainerID, input.Hours)
+}
+
+// GetTrainerAvailability returns the weekly schedule of the trainer
+// and the exceptions from today on.
+func (s *Service) GetTrainerAvailability(ctx context.Context, actor Actor, trainerID int) (models.Availability, error) {
+	if scope := actor.trainerScope(); scope != 0 {
+		trainerID = scope
+	}
+
+	hours, err := s.repos.GetTrainerWorkingHours(ctx, trainerID)
+	if err != nil {
+		return models.Availability{}, err
+	}
+
+	today := truncateDay(time.Now())
+	exceptions, err := s.repos.GetAvailabilityExceptions(ctx, trainerID, today, today.AddDate(1, 0, 0))
+	if err != nil {
+		return models.Availability{}, err
+	}
+	return models.Availability{Hours: hours, Exceptions: exceptions}, nil
+}
+
+func (s *Service) CreateAvailabilityException(ctx context.Context, exception models.AvailabilityException) error {
+	if _, err := s.repos.GetTrainerByID(ctx, exception.TrainerID); err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrTrainerNotFound
+		}
+		return err
+	}
+	if exception.Date.IsZero() {
+		return ErrDateRequired
+	}
+	if exception.StartsAt != nil || exception.EndsAt != nil {
+		if err := validateHours(exception.StartsAt, exception.EndsAt); err != nil {
+			return err
+		}
+	}
+
+	return s.repos.CreateAvailabilityException(ctx, exception)
+}
+
+func (s *Service) DeleteAvailabilityException(ctx context.Context, id int) error {
+	err := s.repos.DeleteAvailabilityException(ctx, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetFreeSlots lists start times between the from and to days when the
+// trainer works and is free for a workout of the given type. Trainers
+// without working hours only have slots on days an exception sets hours.
+func (s *Service) GetFreeSlots(ctx context.Context, actor Actor, trainerID, workoutTypeID int, from, to time.Time) ([]models.TimeSlot, error) {
+	if scope := actor.trainerScope(); scope != 0 {
+		trainerID = scope
+	}
+
+	from, to = truncateDay(from), truncateDay(to)
+	if to.Before(from) || to.Sub(from) > maxSlotDays*24*time.Hour {
+		return nil, ErrInvalidRange
+	}
+
+	workoutType, err := s.repos.GetWorkoutTypeByID(ctx, workoutTypeID)
+	if err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return nil, ErrWorkoutTypeNotFound
+		}
+		return nil, err
+	}
+	duration := time.Duration(workoutType.Duration) * time.Minute
+
+	hours, err := s.repos.GetTrainerWorkingHours(ctx, trainerID)
+	if err != nil {
+		return nil, err
+	}
+	exceptions, err := s.repos.GetAvailabilityExceptions(ctx, trainerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	busy, err := s.repos.GetTrainerBusyTimes(ctx, trainerID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var slots = make([]models.TimeSlot, 0)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		for _, interval := range workingIntervals(hours, exceptions, day) {
+			for start := interval.StartsAt; !start.Add(duration).After(interval.EndsAt); start = start.Add(slotStep) {
+				slot := models.TimeSlot{StartsAt: start, EndsAt: start.Add(duration)}
+				if slot.StartsAt.Before(now) || overlapsAny(slot, busy) {
+					continue
+				}
+				slots = append(slots, slot)
+			}
+		}
+	}
+	return slots, nil
+}
+
+// checkAvailability makes sure the workout fits into the trainer's working
+// hours and is not on a day off.
+func (s *Service) checkAvailability(ctx context.Context, workout models.WorkoutRequest) error {
+	hours, err := s.repos.GetTrainerWorkingHours(ctx, workout.TrainerID)
+	if err != nil {
+		return err
+	}
+
+	day := truncateDay(workout.Date)
+	exceptions, err := s.repos.GetAvailabilityExceptions(ctx, workout.TrainerID, day, day)
+	if err != nil {
+		return err
+	}
+	// trainers without weekly hours can be booked at any time of a day
+	// that has no exceptions, even past midnight
+	if len(hours) == 0 && len(exceptions) == 0 {
+		return nil
+	}
+
+	for _, interval := range workingIntervals(hours, exceptions, day) {
+		if !workout.Date.Before(interval.StartsAt) && !workout.EndsAt.After(interval.EndsAt) {
+			return nil
+		}
+	}
+	return ErrTrainerUnavailable
+}
+
+// workingIntervals returns when the trainer works on the day. Exceptions
+// dated that day replace the weekly hours.
+func workingIntervals(hours []models.WorkingHours, exceptions []models.AvailabilityException, day time.Time) []models.TimeSlot {
+	var (
+		intervals  []models.TimeSlot
+		hasExcepts bool
+	)
+	for _, e := range exceptions {
+		if !sameDay(e.Date, day) {
+			continue
+		}
+		hasExcepts = true
+		if e.StartsAt != nil && e.EndsAt != nil {
+			intervals = append(intervals, clockInterval(day, *e.StartsAt, *e.EndsAt))
+		}
+	}
+	if hasExcepts {
+		return intervals
+	}
+
+	for _, h := range hours {
+		if time.Weekday(h.Weekday) == day.Weekday() {
+			intervals = append(intervals, clockInterval(day, h.StartsAt, h.EndsAt))
+		}
+	}
+	return intervals
+}
+
+func clockInterval(day time.Time, start, end string) models.TimeSlot {
+	startsAt, _ := parseClock(start)
+	endsAt, _ := parseClock(end)
+	return models.TimeSlot{StartsAt: day.Add(startsAt), EndsAt: day.Add(endsAt)}
+}
+
+func overlapsAny(slot models.TimeSlot, busy []models.TimeSlot) bool {
+	for _, b := range busy {
+		if slot.StartsAt.Before(b.EndsAt) && slot.EndsAt.After(b.StartsAt) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateHours(start, end *string) error {
+	if start == nil || end == nil {
+		return ErrInvalidHours
+	}
+	startsAt, err := parseClock(*start)
+	if err != nil {
+		return ErrInvalidHours
+	}
+	endsAt, err := parseClock(*end)
+	if err != nil {
+		return ErrInvalidHours
+	}
+	if startsAt >= endsAt {
+		return ErrInvalidHours
+	}
+	return nil
+}
+
+// parseClock turns "15:04" into the time passed since midnight.
+func parseClock(clock string) (time.Duration, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/Hymiside/fitness-api/pkg/repository"
+	"github.com/gocraft/dbr/v2"
+)
+
+var (
+	ErrInvalidCapacity = errors.New("class capacity must be positive")
+	ErrClassFull       = errors.New("class has no free places")
+	ErrClassClosed     = errors.New("class is not open for booking")
+	ErrAlreadyBooked   = errors.New("client is already booked for this class")
+)
+
+func (s *Service) CreateClassSession(ctx context.Context, actor Actor, class models.ClassSessionRequest) error {
+	if class.Capacity <= 0 {
+		return ErrInvalidCapacity
+	}
+	if class.Date.IsZero() {
+		return ErrDateRequired
+	}
+	if _, err := s.repos.GetTrainerByID(ctx, class.TrainerID); err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrTrainerNotFound
+		}
+		return err
+	}
+	workoutType, err := s.repos.GetWorkoutTypeByID(ctx, class.WorkoutTypeID)
+	if err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrWorkoutTypeNotFound
+		}
+		return err
+	}
+
+	class.AdminID = actor.ID
+	class.EndsAt = class.Date.Add(time.Duration(workoutType.Duration) * time.Minute)
+	class.ResourceID = workoutType.ResourceID
+
+	if class.ResourceID != nil {
+		resource, err := s.repos.GetResourceByID(ctx, *class.ResourceID)
+		if err != nil {
+			return err
+		}
+		if class.Capacity > resource.Capacity {
+			return ErrResourceTooSmall
+		}
+	}
+
+	if err := s.placeClass(ctx, class); err != nil {
+		return err
+	}
+
+	err = s.repos.CreateClassSession(ctx, class)
+	if err != nil {
+		if errors.Is(err, repository.ErrOverlap) {
+			if err := s.placeClass(ctx, class); err != nil {
+				return err
+			}
+			return &ConflictError{WorkoutIDs: []int{}}
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) DeleteClassSession(ctx context.Context, id int) error {
+	err := s.repos.DeleteClassSession(ctx, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) GetClassSessionsByDate(ctx context.Context, actor Actor, date time.Time) ([]models.ClassSessionResponse, error) {
+	classes, err := s.repos.GetClassSessionsByDate(ctx, date, actor.trainerScope())
+	if err != nil {
+		return nil, err
+	}
+	return classes, nil
+}
+
+func (s *Service) GetClassSessionsByInterval(ctx context.Context, actor Actor, from, to time.Time) ([]models.ClassSessionResponse, error) {
+	classes, err := s.repos.GetClassSessionsByInterval(ctx, from, to, actor.trainerScope())
+	if err != nil {
+		return nil, err
+	}
+	return classes, nil
+}
+
+// GetClassSessionByID returns the class together with its attendees.
+func (s *Service) GetClassSessionByID(ctx context.Context, actor Actor, id int) (models.ClassSessionResponse, error) {
+	class, err := s.repos.GetClassSessionByID(ctx, id)
+	if err != nil {
+		return models.ClassSessionResponse{}, err
+	}
+	if scope := actor.trainerScope(); scope != 0 && class.Trainer.ID != scope {
+		return models.ClassSessionResponse{}, dbr.ErrNotFound
+	}
+
+	class.Attendees, err = s.repos.GetClassAttendees(ctx, id)
+	if err != nil {
+		return models.ClassSessionResponse{}, err
+	}
+	return class, nil
+}
+
+func (s *Service) BookClass(ctx context.Context, booking models.ClassBooking) error {
+	if _, err := s.repos.GetClientByID(ctx, booking.ClientID, 0); err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrClientNotFound
+		}
+		return err
+	}
+	if err := s.checkClientFree(ctx, booking); err != nil {
+		return err
+	}
+
+	err := s.repos.BookClass(ctx, booking.ClassID, booking.ClientID)
+	switch {
+	case errors.Is(err, repository.ErrClassFull):
+		return ErrClassFull
+	case errors.Is(err, repository.ErrClassClosed):
+		return ErrClassClosed
+	case errors.Is(err, repository.ErrAlreadyBooked):
+		return ErrAlreadyBooked
+	case errors.Is(err, repository.ErrOverlap):
+		if err := s.checkClientFree(ctx, booking); err != nil {
+			return err
+		}
+		return &ConflictError{WorkoutIDs: []int{}}
+	}
+	return err
+}
+
+// checkClientFree makes sure the client has no workouts or other classes
+// at the time of the class.
+func (s *Service) checkClientFree(ctx context.Context, booking models.ClassBooking) error {
+	class, err := s.repos.GetClassSessionByID(ctx, booking.ClassID)
+	if err != nil {
+		return err
+	}
+
+	ids, err := s.repos.FindConflictingWorkouts(ctx, models.WorkoutRequest{ClientID: booking.ClientID, Date: class.Date, EndsAt: class.EndsAt})
+	if err != nil {
+		return err
+	}
+	classIDs, err := s.repos.FindClientConflictingClasses(ctx, booking.ClientID, class.Date, class.EndsAt, class.ID)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 0 || len(classIDs) != 0 {
+		return &ConflictError{WorkoutIDs: ids, ClassIDs: classIDs}
+	}
+	return nil
+}
+
+// ChangeAttendeeStatus marks one client of the class as done, canceled
+// or no-show. The same transitions as for workouts apply.
+func (s *Service) ChangeAttendeeStatus(ctx context.Context, classID, clientID int, status models.WorkoutStatus) error {
+	if !status.Valid() {
+		return ErrInvalidStatus
+	}
+
+	attendee, err := s.repos.GetClassAttendee(ctx, classID, clientID)
+	if err != nil {
+		return err
+	}
+	if !attendee.Status.CanTransitionTo(status) {
+		return ErrIllegalTransition
+	}
+
+	err = s.repos.ChangeAttendeeStatus(ctx, classID, clientID, attendee.Status, status)
+	if err != nil {
+		if errors.Is(err, repository.ErrStatusChanged) {
+			return ErrIllegalTransition
+		}
+		return err
+	}
+
+	if status == models.StatusCanceled {
+		s.promoteToClass(ctx, classID)
+	}
+	return nil
+}
+
+func (s *Service) ChangeClassStatus(ctx context.Context, id int, status models.WorkoutStatus) error {
+	if !status.Valid() {
+		return ErrInvalidStatus
+	}
+
+	class, err := s.repos.GetClassSessionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !class.Status.CanTransitionTo(status) {
+		return ErrIllegalTransition
+	}
+
+	err = s.repos.ChangeClassStatus(ctx, id, class.Status, status)
+	if err != nil {
+		if errors.Is(err, repository.ErrStatusChanged) {
+			return ErrIllegalTransition
+		}
+		return err
+	}
+	return nil
+}
+
+// placeClass makes sure the trainer works at the time of the class and
+// neither the trainer nor the resource has workouts or other classes then.
+func (s *Service) placeClass(ctx context.Context, class models.ClassSessionRequest) error {
+	slot := models.WorkoutRequest{TrainerID: class.TrainerID, Date: class.Date, EndsAt: class.EndsAt, ResourceID: class.ResourceID}
+	if err := s.checkAvailability(ctx, slot); err != nil {
+		return err
+	}
+
+	ids, err := s.repos.FindConflictingWorkouts(ctx, slot)
+	if err != nil {
+		return err
+	}
+	classIDs, err := s.repos.FindConflictingClasses(ctx, class.TrainerID, class.ResourceID, class.Date, class.EndsAt, class.ID)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 0 || len(classIDs) != 0 {
+		return &ConflictError{WorkoutIDs: ids, ClassIDs: classIDs}
+	}
+	return nil
+}
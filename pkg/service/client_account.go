@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+const clientCodeTTL = 10 * time.Minute
+
+// ErrNoSender is returned when no way to deliver sign-in codes is configured.
+var ErrNoSender = errors.New("sign-in codes cannot be delivered, no sender is configured")
+
+func clientAttemptKey(contact string) string {
+	return "client:" + contact
+}
+
+// SendClientCode delivers a one-time sign-in code to the client with the given
+// phone number or email. Unknown contacts are silently ignored so they cannot be probed.
+// Every send counts against the contact and the IP, so codes cannot be requested
+// in bulk.
+func (s *Service) SendClientCode(ctx context.Context, contact, ip string) error {
+	if s.sender == nil {
+		return ErrNoSender
+	}
+
+	contact = strings.TrimSpace(contact)
+	sendKeys := []string{codeAttemptKey(contact), codeIPAttemptKey(ip)}
+	if err := s.checkLockout(ctx, clientAttemptKey(contact), ipAttemptKey(ip), sendKeys[0], sendKeys[1]); err != nil {
+		return err
+	}
+	if err := s.registerAttempt(ctx, sendKeys...); err != nil {
+		return err
+	}
+
+	client, err := s.repos.GetClientByContact(ctx, contact)
+	if err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	err = s.repos.CreateClientLoginCode(ctx, models.ClientLoginCode{
+		ClientID:  client.ID,
+		CodeHash:  hashToken(code),
+		ExpiresAt: time.Now().Add(clientCodeTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.sender.Send(ctx, contact, fmt.Sprintf("Your sign-in code: %s", code))
+}
+
+func (s *Service) GenerateTokenForClient(ctx context.Context, contact, code, ip string) (models.Tokens, error) {
+	contact = strings.TrimSpace(contact)
+	keys := []string{clientAttemptKey(contact), ipAttemptKey(ip)}
+	if err := s.checkLockout(ctx, keys...); err != nil {
+		return models.Tokens{}, err
+	}
+
+	client, err := s.repos.GetClientByContact(ctx, contact)
+	if err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return models.Tokens{}, s.failSignIn(ctx, keys...)
+		}
+		return models.Tokens{}, err
+	}
+
+	ok, err := s.repos.UseClientLoginCode(ctx, client.ID, hashToken(strings.TrimSpace(code)))
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	if !ok {
+		return models.Tokens{}, s.failSignIn(ctx, keys...)
+	}
+	if err = s.repos.ClearLoginAttempts(ctx, keys[0]); err != nil {
+		return models.Tokens{}, err
+	}
+
+	return s.startSession(ctx, client.ID, RoleClient)
+}
+
+func (s *Service) GetClientWorkouts(ctx context.Context, actor Actor, upcoming bool) ([]models.WorkoutResponse, error) {
+	workouts, err := s.repos.GetClientWorkouts(ctx, actor.ID, upcoming)
+	if err != nil {
+		return nil, err
+	}
+	return workouts, nil
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+var (
+	ErrInvalidRule    = errors.New("percent rules need a percent from 0 to 100, fixed rules an amount, tiered rules tiers with percents from 0 to 100")
+	ErrInvalidGroupBy = errors.New("group_by must be day, week or month")
+)
+
+const (
+	// defaultCompensationPercent is what trainers earn when no rule applies.
+	defaultCompensationPercent = 50
+	// maxEarningDays limits how many days one earnings request may cover.
+	maxEarningDays = 366
+)
+
+func (s *Service) SetCompensationRule(ctx context.Context, rule models.CompensationRule) error {
+	switch rule.Kind {
+	case models.CompensationPercent:
+		if rule.Percent == nil || !isPercent(*rule.Percent) {
+			return ErrInvalidRule
+		}
+		rule.Amount, rule.Tiers = nil, nil
+	case models.CompensationFixed:
+		if rule.Amount == nil || *rule.Amount < 0 {
+			return ErrInvalidRule
+		}
+		rule.Percent, rule.Tiers = nil, nil
+	case models.CompensationTiered:
+		if len(rule.Tiers) == 0 {
+			return ErrInvalidRule
+		}
+		for _, tier := range rule.Tiers {
+			if tier.MinSessions < 0 || !isPercent(tier.Percent) {
+				return ErrInvalidRule
+			}
+		}
+		rule.Percent, rule.Amount = nil, nil
+	default:
+		return ErrInvalidRule
+	}
+
+	if rule.TrainerID != nil {
+		if _, err := s.repos.GetTrainerByID(ctx, *rule.TrainerID); err != nil {
+			if errors.Is(err, dbr.ErrNotFound) {
+				return ErrTrainerNotFound
+			}
+			return err
+		}
+	}
+	if rule.WorkoutTypeID != nil {
+		if _, err := s.repos.GetWorkoutTypeByID(ctx, *rule.WorkoutTypeID); err != nil {
+			if errors.Is(err, dbr.ErrNotFound) {
+				return ErrWorkoutTypeNotFound
+			}
+			return err
+		}
+	}
+
+	return s.repos.SetCompensationRule(ctx, rule)
+}
+
+func (s *Service) DeleteCompensationRule(ctx context.Context, id int) error {
+	err := s.repos.DeleteCompensationRule(ctx, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) GetCompensationRules(ctx context.Context) ([]models.CompensationRule, error) {
+	rules, err := s.repos.GetCompensationRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (s *Service) GetCashByMonth(ctx context.Context, trainerID int) (int, error) {
+	now := time.Now()
+	firstDayOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
+
+	return s.cash(ctx, trainerID, firstDayOfMonth, lastDayOfMonth)
+}
+
+func (s *Service) GetCashByDay(ctx context.Context, trainerID int) (int, error) {
+	now := time.Now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	return s.cash(ctx, trainerID, day, day)
+}
+
+// GetEarnings sums what trainers earned between the from and to days by
+// day, week or month and by workout type. Trainers only see their own
+// earnings, zero trainerID means every trainer.
+func (s *Service) GetEarnings(ctx context.Context, actor Actor, trainerID int, from, to time.Time, groupBy string) (models.Earnings, error) {
+	if scope := actor.trainerScope(); scope != 0 {
+		trainerID = scope
+	}
+	from, to = truncateDay(from), truncateDay(to)
+	if to.Before(from) || to.Sub(from) > maxEarningDays*24*time.Hour {
+		return models.Earnings{}, ErrInvalidRange
+	}
+
+	var period func(time.Time) time.Time
+	switch groupBy {
+	case models.GroupByDay:
+		period = truncateDay
+	case models.GroupByWeek:
+		period = weekStart
+	case models.GroupByMonth:
+		period = monthStart
+	default:
+		return models.Earnings{}, ErrInvalidGroupBy
+	}
+
+	lines, err := s.earnings(ctx, trainerID, from, to)
+	if err != nil {
+		return models.Earnings{}, err
+	}
+
+	type groupKey struct {
+		period        time.Time
+		trainerID     int
+		workoutTypeID int
+	}
+	var (
+		earnings = models.Earnings{Groups: make([]models.EarningGroup, 0)}
+		index    = make(map[groupKey]int)
+	)
+	for _, line := range lines {
+		key := groupKey{period: period(line.Date), trainerID: line.TrainerID, workoutTypeID: line.WorkoutTypeID}
+		i, ok := index[key]
+		if !ok {
+			i = len(earnings.Groups)
+			index[key] = i
+			earnings.Groups = append(earnings.Groups, models.EarningGroup{
+				Period:        key.period,
+				TrainerID:     line.TrainerID,
+				WorkoutTypeID: line.WorkoutTypeID,
+				Title:         line.Title,
+			})
+		}
+
+		if line.Kind == models.EarningKindSession {
+			earnings.Groups[i].Sessions++
+		}
+		earnings.Groups[i].Amount += line.Amount
+		earnings.Total += line.Amount
+	}
+
+	sort.Slice(earnings.Groups, func(i, j int) bool {
+		a, b := earnings.Groups[i], earnings.Groups[j]
+		if !a.Period.Equal(b.Period) {
+			return a.Period.Before(b.Period)
+		}
+		if a.TrainerID != b.TrainerID {
+			return a.TrainerID < b.TrainerID
+		}
+		return a.WorkoutTypeID < b.WorkoutTypeID
+	})
+	return earnings, nil
+}
+
+func (s *Service) cash(ctx context.Context, trainerID int, from, to time.Time) (int, error) {
+	lines, err := s.earnings(ctx, trainerID, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int
+	for _, line := range lines {
+		total += line.Amount
+	}
+	return total, nil
+}
+
+// earnings lists what trainers earned for workouts dated between the from
+// and to days: completed workouts priced by the compensation rules and
+// shares of late cancellation and no-show fees. Zero trainerID means every
+// trainer.
+func (s *Service) earnings(ctx context.Context, trainerID int, from, to time.Time) ([]models.EarningLine, error) {
+	rules, err := s.repos.GetCompensationRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// tiers depend on the volume of whole months, so load them entirely
+	monthEnd := monthStart(to).AddDate(0, 1, -1)
+	sessions, err := s.repos.GetSessionLines(ctx, trainerID, monthStart(from), monthEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	volume := make(map[monthKey]int)
+	for _, line := range sessions {
+		volume[monthOf(line)]++
+	}
+
+	var lines = make([]models.EarningLine, 0, len(sessions))
+	for _, line := range sessions {
+		if !inDays(line.Date, from, to) {
+			continue
+		}
+		rule := matchRule(rules, line.TrainerID, line.WorkoutTypeID)
+		line.Amount, line.Rate = compensate(rule, line.Price, volume[monthOf(line)])
+		lines = append(lines, line)
+	}
+
+	charges, err := s.repos.GetChargeLines(ctx, trainerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return append(lines, charges...), nil
+}
+
+type monthKey struct {
+	trainerID int
+	year      int
+	month     time.Month
+}
+
+func monthOf(line models.EarningLine) monthKey {
+	return monthKey{trainerID: line.TrainerID, year: line.Date.Year(), month: line.Date.Month()}
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// weekStart returns the Monday of the week of t.
+func weekStart(t time.Time) time.Time {
+	day := truncateDay(t)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// inDays reports whether t falls on one of the days from the from day to
+// the to day inclusive.
+func inDays(t, from, to time.Time) bool {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return !day.Before(time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)) &&
+		!day.After(time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC))
+}
+
+// matchRule picks the most specific rule: for the trainer and the workout
+// type, for the trainer, for the workout type, for everyone.
+func matchRule(rules []models.CompensationRule, trainerID, workoutTypeID int) *models.CompensationRule {
+	var (
+		best      *models.CompensationRule
+		bestScore = -1
+	)
+	for i := range rules {
+		rule := &rules[i]
+		score := 0
+		if rule.TrainerID != nil {
+			if *rule.TrainerID != trainerID {
+				continue
+			}
+			score += 2
+		}
+		if rule.WorkoutTypeID != nil {
+			if *rule.WorkoutTypeID != workoutTypeID {
+				continue
+			}
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+	return best
+}
+
+// compensate returns what the trainer earns for one workout of the given
+// price when they completed volume workouts that month, and the rate applied.
+func compensate(rule *models.CompensationRule, price, volume int) (int, string) {
+	if rule == nil {
+		return price * defaultCompensationPercent / 100, fmt.Sprintf("%d%%", defaultCompensationPercent)
+	}
+
+	switch rule.Kind {
+	case models.CompensationFixed:
+		return *rule.Amount, fmt.Sprintf("%d per session", *rule.Amount)
+	case models.CompensationTiered:
+		percent, minSessions := 0, 0
+		for _, tier := range rule.Tiers {
+			if volume >= tier.MinSessions {
+				percent, minSessions = tier.Percent, tier.MinSessions
+			}
+		}
+		return price * percent / 100, fmt.Sprintf("%d%% from %d sessions a month", percent, minSessions)
+	default:
+		return price * *rule.Percent / 100, fmt.Sprintf("%d%%", *rule.Percent)
+	}
+}
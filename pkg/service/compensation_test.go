@@ -0,0 +1,107 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestMatchRule(t *testing.T) {
+	global := models.CompensationRule{ID: 1}
+	byType := models.CompensationRule{ID: 2, WorkoutTypeID: intPtr(3)}
+	byTrainer := models.CompensationRule{ID: 3, TrainerID: intPtr(7)}
+	byBoth := models.CompensationRule{ID: 4, TrainerID: intPtr(7), WorkoutTypeID: intPtr(3)}
+	all := []models.CompensationRule{global, byType, byTrainer, byBoth}
+
+	tests := []struct {
+		name          string
+		rules         []models.CompensationRule
+		trainerID     int
+		workoutTypeID int
+		wantID        int
+	}{
+		{"trainer and type", all, 7, 3, 4},
+		{"trainer over type", []models.CompensationRule{global, byType, byTrainer}, 7, 3, 3},
+		{"type over global", []models.CompensationRule{global, byType}, 7, 3, 2},
+		{"global", all, 8, 4, 1},
+		{"other trainer", all, 8, 3, 2},
+		{"other type", all, 7, 4, 3},
+		{"none", []models.CompensationRule{byBoth}, 8, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchRule(tt.rules, tt.trainerID, tt.workoutTypeID)
+			gotID := 0
+			if got != nil {
+				gotID = got.ID
+			}
+			if gotID != tt.wantID {
+				t.Errorf("matchRule() = rule %d, want rule %d", gotID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestCompensate(t *testing.T) {
+	tiered := &models.CompensationRule{
+		Kind: models.CompensationTiered,
+		Tiers: []models.CompensationTier{
+			{MinSessions: 0, Percent: 30},
+			{MinSessions: 20, Percent: 40},
+			{MinSessions: 40, Percent: 50},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		rule       *models.CompensationRule
+		volume     int
+		wantAmount int
+		wantRate   string
+	}{
+		{"no rule", nil, 0, 500, "50%"},
+		{"percent", &models.CompensationRule{Kind: models.CompensationPercent, Percent: intPtr(35)}, 0, 350, "35%"},
+		{"fixed", &models.CompensationRule{Kind: models.CompensationFixed, Amount: intPtr(600)}, 0, 600, "600 per session"},
+		{"below the second tier", tiered, 19, 300, "30% from 0 sessions a month"},
+		{"at the second tier", tiered, 20, 400, "40% from 20 sessions a month"},
+		{"at the last tier", tiered, 40, 500, "50% from 40 sessions a month"},
+		{"above the last tier", tiered, 90, 500, "50% from 40 sessions a month"},
+		{"below every tier", &models.CompensationRule{Kind: models.CompensationTiered, Tiers: []models.CompensationTier{{MinSessions: 10, Percent: 40}}}, 9, 0, "0% from 0 sessions a month"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, rate := compensate(tt.rule, 1000, tt.volume)
+			if amount != tt.wantAmount || rate != tt.wantRate {
+				t.Errorf("compensate() = %d, %q, want %d, %q", amount, rate, tt.wantAmount, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		t    time.Time
+	}{
+		{"monday midnight", monday},
+		{"wednesday", time.Date(2024, 3, 6, 15, 30, 0, 0, time.UTC)},
+		{"sunday night", time.Date(2024, 3, 10, 23, 59, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weekStart(tt.t); !got.Equal(monday) {
+				t.Errorf("weekStart(%s) = %s, want %s", tt.t, got, monday)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+var ErrInvalidPayment = errors.New("payment needs a positive amount and a method of cash, card or transfer")
+
+// RecordPayment adds money the client paid to their balance.
+func (s *Service) RecordPayment(ctx context.Context, actor Actor, payment models.PaymentInput) error {
+	switch payment.Method {
+	case models.PaymentCash, models.PaymentCard, models.PaymentTransfer:
+	default:
+		return ErrInvalidPayment
+	}
+	if payment.Amount <= 0 {
+		return ErrInvalidPayment
+	}
+
+	if _, err := s.repos.GetClientByID(ctx, payment.ClientID, 0); err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrClientNotFound
+		}
+		return err
+	}
+
+	err := s.repos.CreateLedgerEntry(ctx, models.LedgerEntry{
+		ClientID: payment.ClientID,
+		AdminID:  &actor.ID,
+		Kind:     models.LedgerPayment,
+		Method:   &payment.Method,
+		Amount:   payment.Amount,
+		Note:     payment.Note,
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetClientLedger lists the payments and charges of the client with the
+// balance after each of them. Clients only get their own.
+func (s *Service) GetClientLedger(ctx context.Context, actor Actor, clientID int) ([]models.LedgerEntry, error) {
+	if actor.Role == RoleClient {
+		clientID = actor.ID
+	} else if _, err := s.repos.GetClientByID(ctx, clientID, 0); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repos.GetClientLedger(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *Service) GetDebtors(ctx context.Context) ([]models.ClientBalance, error) {
+	debtors, err := s.repos.GetDebtors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return debtors, nil
+}
+
+// workoutLedgerEntry returns what the client is charged when the workout
+// gets the status: the price of a completed workout not paid with a
+// membership or the fee of a late cancellation or a no-show.
+func workoutLedgerEntry(workout models.WorkoutResponse, status models.WorkoutStatus, charge *models.WorkoutCharge, membershipID *int) *models.LedgerEntry {
+	entry := models.LedgerEntry{ClientID: workout.Client.ID, WorkoutID: &workout.ID}
+	switch {
+	case charge != nil:
+		entry.Kind, entry.Amount = charge.Kind, -charge.Amount
+	case status == models.StatusDone && membershipID == nil:
+		entry.Kind, entry.Amount = models.LedgerWorkout, -workout.WorkoutType.Price
+	}
+
+	if entry.Amount == 0 {
+		return nil
+	}
+	return &entry
+}
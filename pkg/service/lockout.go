@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrTooManyAttempts    = errors.New("too many sign-in attempts, try again later")
+)
+
+const (
+	// freeAttempts failed sign-ins are allowed before a key gets locked.
+	freeAttempts = 5
+	// every failure after that doubles the lockout, starting at lockoutBase.
+	lockoutBase = 30 * time.Second
+	lockoutMax  = time.Hour
+	// failures older than attemptsWindow are forgotten.
+	attemptsWindow = 24 * time.Hour
+)
+
+func (s *Service) GetLoginAttempts(ctx context.Context) ([]models.LoginAttempt, error) {
+	return s.repos.GetLoginAttempts(ctx)
+}
+
+func (s *Service) ClearLoginAttempts(ctx context.Context, key string) error {
+	return s.repos.ClearLoginAttempts(ctx, key)
+}
+
+func adminAttemptKey(login string) string {
+	return "admin:" + login
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Code sends are counted apart from sign-in failures, so asking for codes
+// does not lock out signing in and the other way round.
+func codeAttemptKey(contact string) string {
+	return "code:" + contact
+}
+
+func codeIPAttemptKey(ip string) string {
+	return "code-ip:" + ip
+}
+
+func (s *Service) checkLockout(ctx context.Context, keys ...string) error {
+	attempts, err := s.repos.GetLoginAttempts(ctx, keys...)
+	if err != nil {
+		return err
+	}
+
+	for _, attempt := range attempts {
+		if attempt.LockedUntil != nil && time.Now().Before(*attempt.LockedUntil) {
+			return ErrTooManyAttempts
+		}
+	}
+	return nil
+}
+
+// failSignIn records a failed attempt for every key and always answers with
+// ErrInvalidCredentials so callers cannot tell which part of the credentials was wrong.
+func (s *Service) failSignIn(ctx context.Context, keys ...string) error {
+	if err := s.registerAttempt(ctx, keys...); err != nil {
+		return err
+	}
+	return ErrInvalidCredentials
+}
+
+// registerAttempt counts an attempt for every key and locks the keys that
+// used up their free attempts.
+func (s *Service) registerAttempt(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		failures, err := s.repos.RegisterLoginFailure(ctx, key, attemptsWindow)
+		if err != nil {
+			return err
+		}
+		if failures <= freeAttempts {
+			continue
+		}
+
+		if err = s.repos.LockLoginKey(ctx, key, time.Now().Add(lockoutDuration(failures))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lockoutDuration(failures int) time.Duration {
+	d := lockoutBase
+	for i := freeAttempts + 1; i < failures; i++ {
+		d *= 2
+		if d >= lockoutMax {
+			return lockoutMax
+		}
+	}
+	return d
+}
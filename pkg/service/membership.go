@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+var (
+	ErrInvalidProduct  = errors.New("product needs a title, a kind of package or unlimited, sessions for packages, a positive validity and a price")
+	ErrProductNotFound = errors.New("membership product not found")
+	ErrProductInactive = errors.New("membership product is no longer sold")
+	ErrNoCredit        = errors.New("client has no valid membership credit for this workout")
+)
+
+func (s *Service) CreateMembershipProduct(ctx context.Context, product models.MembershipProduct) error {
+	if err := s.validateProduct(ctx, &product); err != nil {
+		return err
+	}
+
+	err := s.repos.CreateMembershipProduct(ctx, product)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) UpdateMembershipProduct(ctx context.Context, product models.MembershipProduct) error {
+	if err := s.validateProduct(ctx, &product); err != nil {
+		return err
+	}
+
+	err := s.repos.UpdateMembershipProduct(ctx, product)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) GetMembershipProducts(ctx context.Context) ([]models.MembershipProduct, error) {
+	products, err := s.repos.GetMembershipProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// PurchaseMembership sells the product to the client and charges them its
+// price. The membership is valid for the validity days of the product from
+// StartsOn.
+func (s *Service) PurchaseMembership(ctx context.Context, actor Actor, purchase models.MembershipPurchase) error {
+	if _, err := s.repos.GetClientByID(ctx, purchase.ClientID, 0); err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrClientNotFound
+		}
+		return err
+	}
+	product, err := s.repos.GetMembershipProductByID(ctx, purchase.ProductID)
+	if err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	if !product.Active {
+		return ErrProductInactive
+	}
+
+	startsOn := truncateDay(time.Now())
+	if purchase.StartsOn != nil {
+		startsOn = truncateDay(*purchase.StartsOn)
+	}
+
+	var charge *models.LedgerEntry
+	if product.Price > 0 {
+		charge = &models.LedgerEntry{ClientID: purchase.ClientID, Kind: models.LedgerMembership, Amount: -product.Price}
+	}
+
+	err = s.repos.CreateClientMembership(ctx, models.ClientMembership{
+		ClientID:     purchase.ClientID,
+		ProductID:    product.ID,
+		AdminID:      actor.ID,
+		StartsOn:     startsOn,
+		EndsOn:       startsOn.AddDate(0, 0, product.ValidityDays-1),
+		SessionsLeft: product.Sessions,
+		Price:        product.Price,
+	}, charge)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetClientMemberships lists the memberships the client bought. Clients
+// only get their own, trainers those of their clients.
+func (s *Service) GetClientMemberships(ctx context.Context, actor Actor, clientID int) ([]models.ClientMembership, error) {
+	if actor.Role == RoleClient {
+		clientID = actor.ID
+	} else if _, err := s.repos.GetClientByID(ctx, clientID, actor.trainerScope()); err != nil {
+		return nil, err
+	}
+
+	memberships, err := s.repos.GetClientMemberships(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (s *Service) validateProduct(ctx context.Context, product *models.MembershipProduct) error {
+	product.Title = strings.TrimSpace(product.Title)
+	if product.Title == "" || product.ValidityDays <= 0 || product.Price < 0 {
+		return ErrInvalidProduct
+	}
+
+	switch product.Kind {
+	case models.MembershipPackage:
+		if product.Sessions == nil || *product.Sessions <= 0 {
+			return ErrInvalidProduct
+		}
+	case models.MembershipUnlimited:
+		product.Sessions = nil
+	default:
+		return ErrInvalidProduct
+	}
+
+	if product.WorkoutTypeID != nil {
+		if _, err := s.repos.GetWorkoutTypeByID(ctx, *product.WorkoutTypeID); err != nil {
+			if errors.Is(err, dbr.ErrNotFound) {
+				return ErrWorkoutTypeNotFound
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// membershipCredit picks the membership a workout marked done is paid with.
+// Workouts without a membership are paid for separately unless their type
+// requires one: either it is marked membership_required or an active
+// product is sold for that type specifically. Products covering every type
+// do not make a type membership-only, so drop-in sessions stay possible.
+func (s *Service) membershipCredit(ctx context.Context, workout models.WorkoutResponse, status models.WorkoutStatus) (*int, error) {
+	if status != models.StatusDone {
+		return nil, nil
+	}
+
+	membership, err := s.repos.GetUsableMembership(ctx, workout.Client.ID, workout.WorkoutType.ID, workout.Date)
+	if err == nil {
+		return &membership.ID, nil
+	}
+	if !errors.Is(err, dbr.ErrNotFound) {
+		return nil, err
+	}
+
+	workoutType, err := s.repos.GetWorkoutTypeByID(ctx, workout.WorkoutType.ID)
+	if err != nil {
+		return nil, err
+	}
+	if workoutType.MembershipRequired {
+		return nil, ErrNoCredit
+	}
+
+	sold, err := s.repos.HasTypeMembershipProduct(ctx, workoutType.ID)
+	if err != nil {
+		return nil, err
+	}
+	if sold {
+		return nil, ErrNoCredit
+	}
+	return nil, nil
+}
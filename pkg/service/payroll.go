@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/Hymiside/fitness-api/pkg/repository"
+	"github.com/gocraft/dbr/v2"
+)
+
+var (
+	ErrInvalidPeriod = errors.New("payroll period must not end before it starts")
+	ErrPeriodOverlap = errors.New("payroll period overlaps another period")
+	ErrPeriodClosed  = errors.New("payroll period is closed")
+)
+
+// CreatePayrollPeriod opens a payroll period for the days from StartsOn to
+// EndsOn inclusive.
+func (s *Service) CreatePayrollPeriod(ctx context.Context, actor Actor, period models.PayrollPeriod) error {
+	if period.StartsOn.IsZero() || period.EndsOn.IsZero() {
+		return ErrInvalidPeriod
+	}
+	period.StartsOn = truncateDay(period.StartsOn)
+	period.EndsOn = truncateDay(period.EndsOn)
+	if period.EndsOn.Before(period.StartsOn) {
+		return ErrInvalidPeriod
+	}
+
+	period.AdminID = actor.ID
+	err := s.repos.CreatePayrollPeriod(ctx, period)
+	if err != nil {
+		if errors.Is(err, repository.ErrOverlap) {
+			return ErrPeriodOverlap
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) GetPayrollPeriods(ctx context.Context) ([]models.PayrollPeriod, error) {
+	periods, err := s.repos.GetPayrollPeriods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+// ClosePayrollPeriod freezes the statements of the period. From then on the
+// workouts in it can no longer be changed.
+func (s *Service) ClosePayrollPeriod(ctx context.Context, id int) error {
+	period, err := s.repos.GetPayrollPeriodByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if period.Status != models.PayrollOpen {
+		return ErrPeriodClosed
+	}
+
+	lines, err := s.earnings(ctx, 0, period.StartsOn, period.EndsOn)
+	if err != nil {
+		return err
+	}
+
+	err = s.repos.ClosePayrollPeriod(ctx, id, lines)
+	if err != nil {
+		if errors.Is(err, repository.ErrStatusChanged) {
+			return ErrPeriodClosed
+		}
+		return err
+	}
+	return nil
+}
+
+// GetPayrollStatement returns the statement of one trainer for the period.
+// Trainers only get their own statement.
+func (s *Service) GetPayrollStatement(ctx context.Context, actor Actor, periodID, trainerID int) (models.PayrollStatement, error) {
+	if scope := actor.trainerScope(); scope != 0 {
+		trainerID = scope
+	}
+
+	trainer, err := s.repos.GetTrainerByID(ctx, trainerID)
+	if err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return models.PayrollStatement{}, ErrTrainerNotFound
+		}
+		return models.PayrollStatement{}, err
+	}
+
+	statements, err := s.payrollStatements(ctx, periodID, []models.Trainer{trainer})
+	if err != nil {
+		return models.PayrollStatement{}, err
+	}
+	return statements[0], nil
+}
+
+// GetPayrollStatements returns the statements of every trainer for the period.
+func (s *Service) GetPayrollStatements(ctx context.Context, periodID int) ([]models.PayrollStatement, error) {
+	trainers, err := s.repos.GetTrainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.payrollStatements(ctx, periodID, trainers)
+}
+
+// payrollStatements builds the statements of the trainers from the lines
+// saved on closing or, while the period is open, from current earnings.
+func (s *Service) payrollStatements(ctx context.Context, periodID int, trainers []models.Trainer) ([]models.PayrollStatement, error) {
+	period, err := s.repos.GetPayrollPeriodByID(ctx, periodID)
+	if err != nil {
+		return nil, err
+	}
+
+	trainerID := 0
+	if len(trainers) == 1 {
+		trainerID = trainers[0].ID
+	}
+
+	var lines []models.EarningLine
+	if period.Status == models.PayrollClosed {
+		lines, err = s.repos.GetPayrollLines(ctx, periodID, trainerID)
+	} else {
+		lines, err = s.earnings(ctx, trainerID, period.StartsOn, period.EndsOn)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		statements = make([]models.PayrollStatement, 0, len(trainers))
+		index      = make(map[int]int, len(trainers))
+	)
+	for i, trainer := range trainers {
+		index[trainer.ID] = i
+		statements = append(statements, models.PayrollStatement{
+			PeriodID:  periodID,
+			TrainerID: trainer.ID,
+			FirstName: trainer.FirstName,
+			LastName:  trainer.LastName,
+			Lines:     make([]models.EarningLine, 0),
+		})
+	}
+	for _, line := range lines {
+		i, ok := index[line.TrainerID]
+		if !ok {
+			continue
+		}
+		statements[i].Lines = append(statements[i].Lines, line)
+		statements[i].Total += line.Amount
+	}
+	return statements, nil
+}
+
+// checkPeriodOpen makes sure none of the dates belongs to a closed payroll
+// period, so that paid workouts are not changed or added afterwards.
+func (s *Service) checkPeriodOpen(ctx context.Context, dates ...time.Time) error {
+	for _, date := range dates {
+		closed, err := s.repos.IsDateInClosedPeriod(ctx, date)
+		if err != nil {
+			return err
+		}
+		if closed {
+			return ErrPeriodClosed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+var (
+	ErrInvalidPolicy     = errors.New("fee percentages must be between 0 and 100 and the free cancel window not negative")
+	ErrInvalidCanceledAt = errors.New("canceled_at only applies to cancellations and cannot be in the future")
+)
+
+func (s *Service) GetCancellationPolicy(ctx context.Context) (models.CancellationPolicy, error) {
+	policy, err := s.repos.GetCancellationPolicy(ctx)
+	if err != nil {
+		return models.CancellationPolicy{}, err
+	}
+	return policy, nil
+}
+
+func (s *Service) UpdateCancellationPolicy(ctx context.Context, policy models.CancellationPolicy) error {
+	if policy.FreeCancelMinutes < 0 || !isPercent(policy.LateCancelFeePercent) ||
+		!isPercent(policy.NoShowFeePercent) || !isPercent(policy.TrainerSharePercent) {
+		return ErrInvalidPolicy
+	}
+
+	policy.UpdatedAt = time.Now()
+	err := s.repos.UpdateCancellationPolicy(ctx, policy)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// workoutCharge returns the fee the client owes when the workout moves to
+// the requested status now, or nil when there is nothing to charge or the
+// fee is waived. Late cancellation is judged by when the client canceled.
+func (s *Service) workoutCharge(ctx context.Context, workout models.WorkoutResponse, req models.StatusChangeRequest, now time.Time) (*models.WorkoutCharge, error) {
+	canceledAt, err := cancelTime(req, now)
+	if err != nil {
+		return nil, err
+	}
+	if req.Waive || (req.Status != models.StatusCanceled && req.Status != models.StatusNoShow) {
+		return nil, nil
+	}
+
+	policy, err := s.repos.GetCancellationPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return policyCharge(policy, workout, req.Status, canceledAt, now), nil
+}
+
+// cancelTime returns when the client canceled: the reported time, which
+// cannot be in the future, or now.
+func cancelTime(req models.StatusChangeRequest, now time.Time) (time.Time, error) {
+	if req.CanceledAt == nil {
+		return now, nil
+	}
+	if req.Status != models.StatusCanceled || req.CanceledAt.After(now) {
+		return time.Time{}, ErrInvalidCanceledAt
+	}
+	return *req.CanceledAt, nil
+}
+
+// policyCharge applies the policy to a workout canceled at canceledAt or
+// missed. It returns nil when the fee comes to nothing.
+func policyCharge(policy models.CancellationPolicy, workout models.WorkoutResponse, status models.WorkoutStatus, canceledAt, now time.Time) *models.WorkoutCharge {
+	charge := models.WorkoutCharge{WorkoutID: workout.ID, CreatedAt: now}
+	switch status {
+	case models.StatusCanceled:
+		freeUntil := workout.Date.Add(-time.Duration(policy.FreeCancelMinutes) * time.Minute)
+		if canceledAt.Before(freeUntil) {
+			return nil
+		}
+		charge.Kind = models.ChargeLateCancel
+		charge.Amount = workout.WorkoutType.Price * policy.LateCancelFeePercent / 100
+	case models.StatusNoShow:
+		charge.Kind = models.ChargeNoShow
+		charge.Amount = workout.WorkoutType.Price * policy.NoShowFeePercent / 100
+	}
+	if charge.Amount == 0 {
+		return nil
+	}
+
+	charge.TrainerAmount = charge.Amount * policy.TrainerSharePercent / 100
+	return &charge
+}
+
+func isPercent(n int) bool {
+	return n >= 0 && n <= 100
+}
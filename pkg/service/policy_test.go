@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+func TestCancelTime(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Minute)
+
+	tests := []struct {
+		name    string
+		req     models.StatusChangeRequest
+		want    time.Time
+		wantErr error
+	}{
+		{"not reported", models.StatusChangeRequest{Status: models.StatusCanceled}, now, nil},
+		{"in the past", models.StatusChangeRequest{Status: models.StatusCanceled, CanceledAt: &earlier}, earlier, nil},
+		{"now", models.StatusChangeRequest{Status: models.StatusCanceled, CanceledAt: &now}, now, nil},
+		{"in the future", models.StatusChangeRequest{Status: models.StatusCanceled, CanceledAt: &later}, time.Time{}, ErrInvalidCanceledAt},
+		{"not a cancel", models.StatusChangeRequest{Status: models.StatusNoShow, CanceledAt: &earlier}, time.Time{}, ErrInvalidCanceledAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cancelTime(tt.req, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cancelTime() error = %v, want %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("cancelTime() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCharge(t *testing.T) {
+	policy := models.CancellationPolicy{
+		FreeCancelMinutes:    120,
+		LateCancelFeePercent: 50,
+		NoShowFeePercent:     100,
+		TrainerSharePercent:  40,
+	}
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	var workout models.WorkoutResponse
+	workout.ID = 5
+	workout.Date = time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC)
+	workout.WorkoutType.Price = 1000
+	freeUntil := workout.Date.Add(-2 * time.Hour)
+
+	tests := []struct {
+		name       string
+		policy     models.CancellationPolicy
+		status     models.WorkoutStatus
+		canceledAt time.Time
+		want       *models.WorkoutCharge
+	}{
+		{"canceled in time", policy, models.StatusCanceled, freeUntil.Add(-time.Second), nil},
+		{"canceled at the end of the free window", policy, models.StatusCanceled, freeUntil,
+			&models.WorkoutCharge{WorkoutID: 5, Kind: models.ChargeLateCancel, Amount: 500, TrainerAmount: 200, CreatedAt: now}},
+		{"canceled late", policy, models.StatusCanceled, workout.Date.Add(-time.Minute),
+			&models.WorkoutCharge{WorkoutID: 5, Kind: models.ChargeLateCancel, Amount: 500, TrainerAmount: 200, CreatedAt: now}},
+		{"no-show", policy, models.StatusNoShow, now,
+			&models.WorkoutCharge{WorkoutID: 5, Kind: models.ChargeNoShow, Amount: 1000, TrainerAmount: 400, CreatedAt: now}},
+		{"no fee", models.CancellationPolicy{FreeCancelMinutes: 120}, models.StatusNoShow, now, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policyCharge(tt.policy, workout, tt.status, tt.canceledAt, now)
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("policyCharge() = %+v, want no charge", *got)
+			case tt.want != nil && got == nil:
+				t.Errorf("policyCharge() = nil, want %+v", *tt.want)
+			case tt.want != nil && *got != *tt.want:
+				t.Errorf("policyCharge() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/Hymiside/fitness-api/pkg/repository"
+)
+
+var (
+	ErrReasonRequired   = errors.New("reason is required")
+	ErrNotReschedulable = errors.New("only pending workouts can be rescheduled")
+)
+
+// RescheduleWorkout moves a pending workout to another time after the same
+// checks as booking it and records who moved it and why.
+func (s *Service) RescheduleWorkout(ctx context.Context, actor Actor, req models.RescheduleRequest) error {
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return ErrReasonRequired
+	}
+	if req.Date.IsZero() {
+		return ErrDateRequired
+	}
+
+	current, err := s.repos.GetWorkoutByID(ctx, req.WorkoutID)
+	if err != nil {
+		return err
+	}
+	if current.Status != models.StatusPending {
+		return ErrNotReschedulable
+	}
+	if err := s.checkPeriodOpen(ctx, current.Date); err != nil {
+		return err
+	}
+
+	workout, err := s.repos.GetWorkoutRequestByID(ctx, req.WorkoutID)
+	if err != nil {
+		return err
+	}
+	from, fromEndsAt := workout.Date, workout.EndsAt
+	workout.Date = req.Date
+
+	if err := s.placeWorkout(ctx, &workout); err != nil {
+		return err
+	}
+
+	err = s.repos.RescheduleWorkout(ctx, workout, models.WorkoutReschedule{
+		WorkoutID: workout.ID,
+		FromDate:  from,
+		ToDate:    workout.Date,
+		ActorID:   actor.ID,
+		ActorRole: actor.Role,
+		Reason:    req.Reason,
+		MovedAt:   time.Now(),
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrStatusChanged) {
+			return ErrNotReschedulable
+		}
+		return s.overlapError(ctx, workout, err)
+	}
+
+	s.promoteToSlot(ctx, workout.TrainerID, from, fromEndsAt)
+	return nil
+}
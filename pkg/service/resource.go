@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+var (
+	ErrInvalidResource  = errors.New("resource needs a title, a kind of room or equipment and a positive capacity")
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrResourceTooSmall = errors.New("class capacity exceeds the capacity of its resource")
+)
+
+func (s *Service) CreateResource(ctx context.Context, resource models.Resource) error {
+	if err := validateResource(&resource); err != nil {
+		return err
+	}
+
+	err := s.repos.CreateResource(ctx, resource)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) UpdateResource(ctx context.Context, resource models.Resource) error {
+	if err := validateResource(&resource); err != nil {
+		return err
+	}
+
+	err := s.repos.UpdateResource(ctx, resource)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) DeleteResource(ctx context.Context, id int) error {
+	err := s.repos.DeleteResource(ctx, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) GetResourceByID(ctx context.Context, id int) (models.Resource, error) {
+	resource, err := s.repos.GetResourceByID(ctx, id)
+	if err != nil {
+		return models.Resource{}, err
+	}
+	return resource, nil
+}
+
+func (s *Service) GetResources(ctx context.Context) ([]models.Resource, error) {
+	resources, err := s.repos.GetResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+func validateResource(resource *models.Resource) error {
+	if resource.Kind == "" {
+		resource.Kind = models.ResourceRoom
+	}
+	if resource.Capacity == 0 {
+		resource.Capacity = 1
+	}
+
+	if resource.Title == "" || resource.Capacity < 0 {
+		return ErrInvalidResource
+	}
+	if resource.Kind != models.ResourceRoom && resource.Kind != models.ResourceEquipment {
+		return ErrInvalidResource
+	}
+	return nil
+}
+
+// checkResource makes sure the resource a workout type refers to exists.
+func (s *Service) checkResource(ctx context.Context, id *int) error {
+	if id == nil {
+		return nil
+	}
+
+	if _, err := s.repos.GetResourceByID(ctx, *id); err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrResourceNotFound
+		}
+		return err
+	}
+	return nil
+}
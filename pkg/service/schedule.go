@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/Hymiside/fitness-api/pkg/repository"
+	"github.com/gocraft/dbr/v2"
+)
+
+var (
+	ErrWorkoutConflict     = errors.New("workout overlaps with existing workouts")
+	ErrDateRequired        = errors.New("workout date is required")
+	ErrClientNotFound      = errors.New("client not found")
+	ErrTrainerNotFound     = errors.New("trainer not found")
+	ErrWorkoutTypeNotFound = errors.New("workout type not found")
+	ErrInvalidStatus       = errors.New("invalid workout status")
+	ErrIllegalTransition   = errors.New("workout status cannot be changed this way")
+	ErrNotDeletable        = errors.New("only pending workouts can be deleted, cancel it instead")
+)
+
+const defaultWorkoutDuration = 60
+
+// ConflictError lists the workouts and the group classes a new or moved
+// workout would overlap with.
+type ConflictError struct {
+	WorkoutIDs []int
+	ClassIDs   []int
+}
+
+func (e *ConflictError) Error() string {
+	return ErrWorkoutConflict.Error()
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrWorkoutConflict
+}
+
+// validateWorkout makes sure everything the workout refers to exists.
+func (s *Service) validateWorkout(ctx context.Context, workout models.WorkoutRequest) error {
+	if workout.Date.IsZero() {
+		return ErrDateRequired
+	}
+
+	if _, err := s.repos.GetClientByID(ctx, workout.ClientID, 0); err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrClientNotFound
+		}
+		return err
+	}
+	if _, err := s.repos.GetTrainerByID(ctx, workout.TrainerID); err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrTrainerNotFound
+		}
+		return err
+	}
+	if _, err := s.repos.GetWorkoutTypeByID(ctx, workout.WorkoutTypeID); err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrWorkoutTypeNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// placeWorkout computes when the workout ends and which resource it takes from
+// its type and makes sure the trainer works then and neither the trainer, the
+// client nor the resource is busy.
+func (s *Service) placeWorkout(ctx context.Context, workout *models.WorkoutRequest) error {
+	if err := s.checkPeriodOpen(ctx, workout.Date); err != nil {
+		return err
+	}
+
+	workoutType, err := s.repos.GetWorkoutTypeByID(ctx, workout.WorkoutTypeID)
+	if err != nil {
+		return err
+	}
+	workout.EndsAt = workout.Date.Add(time.Duration(workoutType.Duration) * time.Minute)
+	workout.ResourceID = workoutType.ResourceID
+
+	if err := s.checkAvailability(ctx, *workout); err != nil {
+		return err
+	}
+
+	ids, err := s.repos.FindConflictingWorkouts(ctx, *workout)
+	if err != nil {
+		return err
+	}
+	classIDs, err := s.findConflictingClasses(ctx, *workout)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 0 || len(classIDs) != 0 {
+		return &ConflictError{WorkoutIDs: ids, ClassIDs: classIDs}
+	}
+	return nil
+}
+
+// findConflictingClasses returns IDs of classes that overlap the workout and
+// have its trainer or resource or are booked by its client.
+func (s *Service) findConflictingClasses(ctx context.Context, workout models.WorkoutRequest) ([]int, error) {
+	ids, err := s.repos.FindConflictingClasses(ctx, workout.TrainerID, workout.ResourceID, workout.Date, workout.EndsAt, 0)
+	if err != nil {
+		return nil, err
+	}
+	booked, err := s.repos.FindClientConflictingClasses(ctx, workout.ClientID, workout.Date, workout.EndsAt, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[int]bool, len(ids)+len(booked))
+	for _, id := range append(ids, booked...) {
+		set[id] = true
+	}
+	return sortedIDs(set), nil
+}
+
+// overlapError reports a booking that lost a race against a concurrent one
+// and was rejected by the database constraint.
+func (s *Service) overlapError(ctx context.Context, workout models.WorkoutRequest, err error) error {
+	if !errors.Is(err, repository.ErrOverlap) {
+		return err
+	}
+
+	ids, findErr := s.repos.FindConflictingWorkouts(ctx, workout)
+	if findErr != nil {
+		return findErr
+	}
+	classIDs, findErr := s.findConflictingClasses(ctx, workout)
+	if findErr != nil {
+		return findErr
+	}
+	return &ConflictError{WorkoutIDs: ids, ClassIDs: classIDs}
+}
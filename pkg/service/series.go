@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/Hymiside/fitness-api/pkg/repository"
+)
+
+var (
+	ErrInvalidSeries = errors.New("series needs a weekly or biweekly frequency and an end date or count")
+	ErrSeriesTooLong = errors.New("series has too many occurrences")
+	ErrInvalidScope  = errors.New("scope must be single, following or all")
+	ErrNotInSeries   = errors.New("workout is not a pending occurrence of a series")
+)
+
+// maxSeriesOccurrences caps how many workouts one series may create,
+// two years of weekly workouts.
+const maxSeriesOccurrences = 104
+
+func (s *Service) CreateWorkoutSeries(ctx context.Context, actor Actor, input models.WorkoutSeriesRequest) (models.WorkoutSeriesResponse, error) {
+	series := models.WorkoutSeries{
+		ClientID:      input.ClientID,
+		TrainerID:     input.TrainerID,
+		WorkoutTypeID: input.WorkoutTypeID,
+		AdminID:       actor.ID,
+		StartsAt:      input.StartsAt,
+		Until:         input.Until,
+	}
+
+	switch input.Frequency {
+	case models.FrequencyWeekly:
+		series.IntervalWeeks = 1
+	case models.FrequencyBiweekly:
+		series.IntervalWeeks = 2
+	default:
+		return models.WorkoutSeriesResponse{}, ErrInvalidSeries
+	}
+	if input.Count > 0 {
+		series.Count = &input.Count
+	}
+
+	dates, err := seriesDates(series)
+	if err != nil {
+		return models.WorkoutSeriesResponse{}, err
+	}
+
+	workouts := make([]models.WorkoutRequest, 0, len(dates))
+	for _, date := range dates {
+		workouts = append(workouts, models.WorkoutRequest{
+			ClientID:      series.ClientID,
+			TrainerID:     series.TrainerID,
+			WorkoutTypeID: series.WorkoutTypeID,
+			AdminID:       series.AdminID,
+			Date:          date,
+		})
+	}
+
+	if err := s.validateWorkout(ctx, workouts[0]); err != nil {
+		return models.WorkoutSeriesResponse{}, err
+	}
+	if err := s.placeWorkouts(ctx, workouts); err != nil {
+		return models.WorkoutSeriesResponse{}, err
+	}
+
+	seriesID, ids, err := s.repos.CreateWorkoutSeries(ctx, series, workouts)
+	if err != nil {
+		return models.WorkoutSeriesResponse{}, s.batchOverlapError(ctx, workouts, err)
+	}
+	return models.WorkoutSeriesResponse{ID: seriesID, WorkoutIDs: ids}, nil
+}
+
+// EditWorkoutSeries changes the trainer, the workout type or the time of
+// the selected occurrence and, depending on the scope, the ones after it
+// or the whole series.
+func (s *Service) EditWorkoutSeries(ctx context.Context, edit models.SeriesEdit) error {
+	workouts, err := s.seriesScope(ctx, edit.WorkoutID, edit.Scope)
+	if err != nil {
+		return err
+	}
+
+	var offset time.Duration
+	for _, workout := range workouts {
+		if err := s.checkPeriodOpen(ctx, workout.Date); err != nil {
+			return err
+		}
+		if edit.Date != nil && workout.ID == edit.WorkoutID {
+			offset = edit.Date.Sub(workout.Date)
+		}
+	}
+
+	for i := range workouts {
+		if edit.TrainerID != 0 {
+			workouts[i].TrainerID = edit.TrainerID
+		}
+		if edit.WorkoutTypeID != 0 {
+			workouts[i].WorkoutTypeID = edit.WorkoutTypeID
+		}
+		workouts[i].Date = workouts[i].Date.Add(offset)
+	}
+
+	if err := s.validateWorkout(ctx, workouts[0]); err != nil {
+		return err
+	}
+	if err := s.placeWorkouts(ctx, workouts); err != nil {
+		return err
+	}
+
+	err = s.repos.UpdateWorkouts(ctx, workouts)
+	if err != nil {
+		return s.batchOverlapError(ctx, workouts, err)
+	}
+	return nil
+}
+
+// CancelWorkoutSeries cancels the selected occurrence and, depending on the
+// scope, the ones after it or the whole series. The studio cancels them, so
+// no fees are charged. It returns the IDs of the canceled workouts.
+func (s *Service) CancelWorkoutSeries(ctx context.Context, actor Actor, cancel models.SeriesCancel) ([]int, error) {
+	workouts, err := s.seriesScope(ctx, cancel.WorkoutID, cancel.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var canceled = make([]int, 0, len(workouts))
+	for _, workout := range workouts {
+		err := s.ChangeStatusWorkout(ctx, actor, models.StatusChangeRequest{
+			WorkoutID: workout.ID,
+			Status:    models.StatusCanceled,
+			Waive:     true,
+		})
+		if err != nil {
+			// the occurrence was completed or canceled in the meantime
+			// or belongs to a closed payroll period
+			if errors.Is(err, ErrIllegalTransition) || errors.Is(err, ErrPeriodClosed) {
+				continue
+			}
+			return canceled, err
+		}
+		canceled = append(canceled, workout.ID)
+	}
+	return canceled, nil
+}
+
+// seriesDates lists the start of every occurrence of the series.
+func seriesDates(series models.WorkoutSeries) ([]time.Time, error) {
+	if series.StartsAt.IsZero() {
+		return nil, ErrDateRequired
+	}
+	if series.Until == nil && series.Count == nil {
+		return nil, ErrInvalidSeries
+	}
+	if series.Count != nil && *series.Count > maxSeriesOccurrences {
+		return nil, ErrSeriesTooLong
+	}
+
+	var dates []time.Time
+	for i := 0; series.Count == nil || i < *series.Count; i++ {
+		date := series.StartsAt.AddDate(0, 0, 7*series.IntervalWeeks*i)
+		if series.Until != nil && date.After(*series.Until) {
+			break
+		}
+		if i == maxSeriesOccurrences {
+			return nil, ErrSeriesTooLong
+		}
+		dates = append(dates, date)
+	}
+
+	if len(dates) == 0 {
+		return nil, ErrInvalidSeries
+	}
+	return dates, nil
+}
+
+// seriesScope returns the pending occurrences the scope selects, starting
+// from the given workout.
+func (s *Service) seriesScope(ctx context.Context, workoutID int, scope string) ([]models.WorkoutRequest, error) {
+	switch scope {
+	case models.SeriesScopeSingle, models.SeriesScopeFollowing, models.SeriesScopeAll:
+	default:
+		return nil, ErrInvalidScope
+	}
+
+	selected, err := s.repos.GetWorkoutRequestByID(ctx, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	if selected.SeriesID == nil {
+		return nil, ErrNotInSeries
+	}
+
+	from := selected.Date
+	if scope == models.SeriesScopeAll {
+		from = time.Time{}
+	}
+
+	workouts, err := s.repos.GetPendingSeriesWorkouts(ctx, *selected.SeriesID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, workout := range workouts {
+		if workout.ID != selected.ID {
+			continue
+		}
+		if scope == models.SeriesScopeSingle {
+			return []models.WorkoutRequest{workout}, nil
+		}
+		return workouts, nil
+	}
+	return nil, ErrNotInSeries
+}
+
+// placeWorkouts does what placeWorkout does for a batch of workouts that are
+// saved together, so they are not reported as conflicting with each other.
+func (s *Service) placeWorkouts(ctx context.Context, workouts []models.WorkoutRequest) error {
+	var (
+		types          = make(map[int]models.WorkoutType)
+		batch          = make(map[int]bool)
+		conflicts      = make(map[int]bool)
+		classConflicts = make(map[int]bool)
+	)
+	for _, workout := range workouts {
+		batch[workout.ID] = true
+	}
+
+	for i := range workouts {
+		workoutType, ok := types[workouts[i].WorkoutTypeID]
+		if !ok {
+			var err error
+			workoutType, err = s.repos.GetWorkoutTypeByID(ctx, workouts[i].WorkoutTypeID)
+			if err != nil {
+				return err
+			}
+			types[workouts[i].WorkoutTypeID] = workoutType
+		}
+		workouts[i].EndsAt = workouts[i].Date.Add(time.Duration(workoutType.Duration) * time.Minute)
+		workouts[i].ResourceID = workoutType.ResourceID
+
+		if err := s.checkPeriodOpen(ctx, workouts[i].Date); err != nil {
+			return err
+		}
+		if err := s.checkAvailability(ctx, workouts[i]); err != nil {
+			return err
+		}
+
+		ids, err := s.repos.FindConflictingWorkouts(ctx, workouts[i])
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if !batch[id] {
+				conflicts[id] = true
+			}
+		}
+
+		classIDs, err := s.findConflictingClasses(ctx, workouts[i])
+		if err != nil {
+			return err
+		}
+		for _, id := range classIDs {
+			classConflicts[id] = true
+		}
+	}
+
+	if len(conflicts) != 0 || len(classConflicts) != 0 {
+		return &ConflictError{WorkoutIDs: sortedIDs(conflicts), ClassIDs: sortedIDs(classConflicts)}
+	}
+	return nil
+}
+
+func sortedIDs(set map[int]bool) []int {
+	ids := make([]int, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// batchOverlapError is overlapError for workouts saved by placeWorkouts.
+func (s *Service) batchOverlapError(ctx context.Context, workouts []models.WorkoutRequest, err error) error {
+	if !errors.Is(err, repository.ErrOverlap) {
+		return err
+	}
+
+	if err := s.placeWorkouts(ctx, workouts); err != nil {
+		return err
+	}
+	return &ConflictError{WorkoutIDs: []int{}}
+}
@@ -2,13 +2,17 @@ package service
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"time"
 
 	"github.com/Hymiside/fitness-api/pkg/models"
 	"github.com/Hymiside/fitness-api/pkg/repository"
+	"github.com/Hymiside/fitness-api/pkg/sender"
+	"github.com/gocraft/dbr/v2"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -22,163 +26,314 @@ var (
 	ErrParseJWT    = errors.New("error to parse jwt-token")
 	ErrSignMethod  = errors.New("invalid signing method")
 	ErrHashPwd     = errors.New("error to hash password")
+	ErrEmptyPwd    = errors.New("password is empty")
+	ErrUnknownKey  = errors.New("unknown signing key")
 )
 
-var (
-	signingKey = []byte("qrkjk#4#%35FSFJlja#4353KSFjH")
-	tokenTTL   = 1460 * time.Hour
+const (
+	RoleSudo    = "sudo"
+	RoleAdmin   = "admin"
+	RoleTrainer = "trainer"
+	RoleClient  = "client"
 )
 
+// Actor is the authenticated user a request is made on behalf of.
+type Actor struct {
+	ID   int
+	Role string
+}
+
+// noScope is a trainer scope that matches no trainer.
+const noScope = -1
+
+// trainerScope returns the trainer whose data the actor is limited to,
+// or 0 when the actor may see everything. Only staff see everything, any
+// other role sees nothing.
+func (a Actor) trainerScope() int {
+	switch a.Role {
+	case RoleSudo, RoleAdmin:
+		return 0
+	case RoleTrainer:
+		return a.ID
+	}
+	return noScope
+}
+
 type Claims struct {
 	jwt.StandardClaims
-	UserID int
-	Role   string
+	UserID    int
+	Role      string
+	SessionID int
+}
+
+type Config struct {
+	// SigningKeys holds every key tokens may be verified with, by key ID.
+	SigningKeys map[string][]byte
+	// ActiveKeyID selects the key new tokens are signed with.
+	ActiveKeyID string
+	// TokenTTL is the lifetime of access tokens; sessions are extended
+	// with refresh tokens that live for RefreshTokenTTL.
+	TokenTTL        time.Duration
+	RefreshTokenTTL time.Duration
 }
 
 type Service struct {
-	repos *repository.Repository
+	repos  *repository.Repository
+	cfg    Config
+	sender sender.Sender
 }
 
-func NewService(repos *repository.Repository) *Service {
-	return &Service{repos: repos}
+// NewService builds the services. sender may be nil, then clients cannot
+// request sign-in codes.
+func NewService(repos *repository.Repository, cfg Config, sender sender.Sender) *Service {
+	return &Service{repos: repos, cfg: cfg, sender: sender}
 }
 
 func (s *Service) GetAdminType(ctx context.Context, adminID int) (bool, error) {
 	return s.repos.GetAdminType(ctx, adminID)
 }
 
-func (s *Service) GetAdmins(ctx context.Context) ([]models.Admin, error) {
-	return s.repos.GetAdmins(ctx)
+func (s *Service) GetAdmins(ctx context.Context) ([]models.AdminResponse, error) {
+	admins, err := s.repos.GetAdmins(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]models.AdminResponse, 0, len(admins))
+	for _, admin := range admins {
+		response = append(response, admin.Response())
+	}
+	return response, nil
 }
 
 func (s *Service) DeleteAdmin(ctx context.Context, adminID int) error {
-	return s.repos.DeleteAdmin(ctx, adminID)
+	if err := s.repos.DeleteAdmin(ctx, adminID); err != nil {
+		return err
+	}
+	return s.repos.RevokeUserSessions(ctx, adminID, RoleAdmin, RoleSudo)
 }
 
-func (s *Service) CreateAdmin(ctx context.Context, admin models.Admin) error {
-	return s.repos.CreateAdmin(ctx, admin)
-}
+func (s *Service) CreateAdmin(ctx context.Context, input models.AdminInput) error {
+	hash, err := hashPassword(input.Password)
+	if err != nil {
+		return err
+	}
 
-func (s *Service) GetAdminByID(ctx context.Context, adminID int) (models.Admin, error) {
-	return s.repos.GetAdminByID(ctx, adminID)
+	return s.repos.CreateAdmin(ctx, models.Admin{
+		Login:     input.Login,
+		Password:  hash,
+		FirstName: input.FirstName,
+		LastName:  input.LastName,
+		Super:     input.Super,
+	})
 }
 
-func (s *Service) GetWorkoutTypeByID(ctx context.Context, id int) (models.WorkoutType, error) {
-	return s.repos.GetWorkoutTypeByID(ctx, id)
+func (s *Service) ChangeAdminPassword(ctx context.Context, adminID int, oldPassword, newPassword string) error {
+	admin, err := s.repos.GetAdminByID(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if !checkPassword(admin.Password, oldPassword) {
+		return ErrInvalidPwd
+	}
+
+	hash, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	return s.repos.UpdateAdminPassword(ctx, adminID, hash)
 }
 
-func (s *Service) GetCashByMonth(ctx context.Context, trainerID int) (int, error) {
-	return s.repos.GetCashByMonth(ctx, trainerID)
+func (s *Service) GetAdminByID(ctx context.Context, adminID int) (models.AdminResponse, error) {
+	admin, err := s.repos.GetAdminByID(ctx, adminID)
+	if err != nil {
+		return models.AdminResponse{}, err
+	}
+	return admin.Response(), nil
 }
 
-func (s *Service) GetCashByDay(ctx context.Context, trainerID int) (int, error) {
-	return s.repos.GetCashByDay(ctx, trainerID)
+func (s *Service) GetWorkoutTypeByID(ctx context.Context, id int) (models.WorkoutType, error) {
+	return s.repos.GetWorkoutTypeByID(ctx, id)
 }
 
-func (s *Service) GenerateTokenForAdmin(ctx context.Context, login, password string) (string, error) {
+func (s *Service) GenerateTokenForAdmin(ctx context.Context, login, password, ip string) (models.Tokens, error) {
+	keys := []string{adminAttemptKey(login), ipAttemptKey(ip)}
+	if err := s.checkLockout(ctx, keys...); err != nil {
+		return models.Tokens{}, err
+	}
+
 	admin, err := s.repos.GetAdmin(ctx, login)
 	if err != nil {
-		return "", err
+		if !errors.Is(err, dbr.ErrNotFound) {
+			return models.Tokens{}, err
+		}
+		// spend the same time on unknown logins as on wrong passwords
+		checkPassword(dummyPasswordHash, password)
+		return models.Tokens{}, s.failSignIn(ctx, keys...)
+	}
+	if !checkPassword(admin.Password, password) {
+		return models.Tokens{}, s.failSignIn(ctx, keys...)
+	}
+	if err = s.repos.ClearLoginAttempts(ctx, adminAttemptKey(login)); err != nil {
+		return models.Tokens{}, err
+	}
+	if !isPasswordHash(admin.Password) {
+		// rows created before hashing was introduced still hold the plaintext value
+		hash, err := hashPassword(password)
+		if err != nil {
+			return models.Tokens{}, err
+		}
+		if err = s.repos.UpdateAdminPassword(ctx, admin.ID, hash); err != nil {
+			return models.Tokens{}, err
+		}
 	}
-	if admin.Password != password {
-		return "", ErrInvalidPwd
+
+	if admin.TOTPEnabled {
+		mfaToken, err := s.signToken(admin.ID, rolePreAuth, 0, mfaTokenTTL)
+		if err != nil {
+			return models.Tokens{}, err
+		}
+		return models.Tokens{MFAToken: mfaToken}, nil
 	}
 
-	role := "admin"
+	return s.startSession(ctx, admin.ID, adminRole(admin))
+}
+
+func adminRole(admin models.Admin) string {
 	if admin.Super {
-		role = "sudo"
+		return RoleSudo
 	}
+	return RoleAdmin
+}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
-		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(tokenTTL).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
-		admin.ID,
-		role,
-	})
+func (s *Service) GenerateTokenForTrainer(ctx context.Context, token, ip string) (models.Tokens, error) {
+	if err := s.checkLockout(ctx, ipAttemptKey(ip)); err != nil {
+		return models.Tokens{}, err
+	}
 
-	var tokenString string
-	tokenString, err = jwtToken.SignedString(signingKey)
+	trainer, err := s.repos.GetTrainerByToken(ctx, hashToken(token))
 	if err != nil {
-		return "", ErrCreateJWT
+		if errors.Is(err, dbr.ErrNotFound) {
+			return models.Tokens{}, s.failSignIn(ctx, ipAttemptKey(ip))
+		}
+		return models.Tokens{}, err
 	}
-	return tokenString, nil
+
+	return s.startSession(ctx, trainer.ID, RoleTrainer)
 }
 
-func (s *Service) GenerateTokenForTrainer(ctx context.Context, token string) (string, error) {
-	trainer, err := s.repos.GetTrainerByToken(ctx, token)
-	if err != nil {
-		return "", err
+func (s *Service) signToken(userID int, role string, sessionID int, ttl time.Duration) (string, error) {
+	key, ok := s.cfg.SigningKeys[s.cfg.ActiveKeyID]
+	if !ok {
+		return "", ErrUnknownKey
 	}
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
 		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(tokenTTL).Unix(),
+			ExpiresAt: time.Now().Add(ttl).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
-		trainer.ID,
-		"trainer",
+		userID,
+		role,
+		sessionID,
 	})
+	jwtToken.Header["kid"] = s.cfg.ActiveKeyID
 
-	var tokenString string
-	tokenString, err = jwtToken.SignedString(signingKey)
+	tokenString, err := jwtToken.SignedString(key)
 	if err != nil {
 		return "", ErrCreateJWT
 	}
 	return tokenString, nil
 }
 
-func (s *Service) ParseToken(tokenString string) (int, string, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrSignMethod
-		}
-		return signingKey, nil
-	})
+func (s *Service) ParseToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.verificationKey)
 	if err != nil {
-		return 0, "", ErrParseJWT
+		return nil, ErrParseJWT
 	}
 
 	claims, ok := token.Claims.(*Claims)
 	if !ok {
-		return 0, "", ErrTokenClaims
+		return nil, ErrTokenClaims
 	}
-	return claims.UserID, claims.Role, nil
+	return claims, nil
 }
 
-func (s *Service) CreateTrainer(ctx context.Context, trainer models.Trainer) (string, error) {
-	trainer.Token = uuid.New().String()
+// verificationKey picks the key from the keyring by the "kid" header.
+// Tokens issued before key IDs were introduced are checked against the active key.
+func (s *Service) verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, ErrSignMethod
+	}
 
-	token, err := s.repos.CreateTrainer(ctx, trainer)
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		kid = s.cfg.ActiveKeyID
+	}
+	key, ok := s.cfg.SigningKeys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// CreateTrainer returns the trainer's login token. Only its hash is stored,
+// so this is the only time the raw token is available.
+func (s *Service) CreateTrainer(ctx context.Context, input models.TrainerInput) (string, error) {
+	token := uuid.New().String()
+
+	err := s.repos.CreateTrainer(ctx, models.Trainer{
+		TokenHash:      hashToken(token),
+		TokenExpiresAt: input.TokenExpiresAt,
+		FirstName:      input.FirstName,
+		LastName:       input.LastName,
+	})
 	if err != nil {
 		return "", err
 	}
 	return token, nil
 }
 
+func (s *Service) RegenerateTrainerToken(ctx context.Context, id int, expiresAt *time.Time) (string, error) {
+	token := uuid.New().String()
+
+	err := s.repos.UpdateTrainerToken(ctx, id, hashToken(token), expiresAt)
+	if err != nil {
+		return "", err
+	}
+	if err = s.repos.RevokeUserSessions(ctx, id, RoleTrainer); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
 func (s *Service) DeleteTrainer(ctx context.Context, id int) error {
 	err := s.repos.DeleteTrainer(ctx, id)
 	if err != nil {
 		return err
 	}
-	return nil
+	return s.repos.RevokeUserSessions(ctx, id, RoleTrainer)
 }
 
-func (s *Service) GetTrainers(ctx context.Context) ([]models.Trainer, error) {
+func (s *Service) GetTrainers(ctx context.Context) ([]models.TrainerResponse, error) {
 	trainers, err := s.repos.GetTrainers(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return trainers, nil
+
+	response := make([]models.TrainerResponse, 0, len(trainers))
+	for _, trainer := range trainers {
+		response = append(response, trainer.Response())
+	}
+	return response, nil
 }
 
-func (s *Service) GetTrainerByID(ctx context.Context, id int) (models.Trainer, error) {
+func (s *Service) GetTrainerByID(ctx context.Context, id int) (models.TrainerResponse, error) {
 	trainer, err := s.repos.GetTrainerByID(ctx, id)
 	if err != nil {
-		return models.Trainer{}, err
+		return models.TrainerResponse{}, err
 	}
-	return trainer, nil
+	return trainer.Response(), nil
 }
 
 func (s *Service) CreateClient(ctx context.Context, client models.Client) error {
@@ -197,16 +352,16 @@ func (s *Service) UpdateClient(ctx context.Context, client models.Client) error
 	return nil
 }
 
-func (s *Service) GetClients(ctx context.Context) ([]models.Client, error) {
-	clients, err := s.repos.GetClients(ctx)
+func (s *Service) GetClients(ctx context.Context, actor Actor) ([]models.Client, error) {
+	clients, err := s.repos.GetClients(ctx, actor.trainerScope())
 	if err != nil {
 		return nil, err
 	}
 	return clients, nil
 }
 
-func (s *Service) GetClientByID(ctx context.Context, id int) (models.Client, error) {
-	client, err := s.repos.GetClientByID(ctx, id)
+func (s *Service) GetClientByID(ctx context.Context, actor Actor, id int) (models.Client, error) {
+	client, err := s.repos.GetClientByID(ctx, id, actor.trainerScope())
 	if err != nil {
 		return models.Client{}, err
 	}
@@ -214,54 +369,119 @@ func (s *Service) GetClientByID(ctx context.Context, id int) (models.Client, err
 }
 
 func (s *Service) CreateWorkout(ctx context.Context, workout models.WorkoutRequest) error {
+	if err := s.validateWorkout(ctx, workout); err != nil {
+		return err
+	}
+	if err := s.placeWorkout(ctx, &workout); err != nil {
+		return err
+	}
+
 	err := s.repos.CreateWorkout(ctx, workout)
 	if err != nil {
-		return err
+		return s.overlapError(ctx, workout, err)
 	}
 	return nil
 }
 
 func (s *Service) UpdateWorkout(ctx context.Context, workout models.WorkoutRequest) error {
-	err := s.repos.UpdateWorkout(ctx, workout)
+	if err := s.validateWorkout(ctx, workout); err != nil {
+		return err
+	}
+	current, err := s.repos.GetWorkoutRequestByID(ctx, workout.ID)
 	if err != nil {
 		return err
 	}
+	if err := s.checkPeriodOpen(ctx, current.Date); err != nil {
+		return err
+	}
+	if err := s.placeWorkout(ctx, &workout); err != nil {
+		return err
+	}
+
+	err = s.repos.UpdateWorkout(ctx, workout)
+	if err != nil {
+		return s.overlapError(ctx, workout, err)
+	}
 	return nil
 }
 
+// DeleteWorkout removes a pending workout. Completed, canceled and no-show
+// workouts may have used a membership credit or been charged, so they stay.
 func (s *Service) DeleteWorkout(ctx context.Context, id int) error {
-	err := s.repos.DeleteWorkout(ctx, id)
+	workout, err := s.repos.GetWorkoutByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if workout.Status != models.StatusPending {
+		return ErrNotDeletable
+	}
+	if err := s.checkPeriodOpen(ctx, workout.Date); err != nil {
+		return err
+	}
+
+	err = s.repos.DeleteWorkout(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrStatusChanged) {
+			return ErrNotDeletable
+		}
+		return err
+	}
 	return nil
 }
 
-func (s *Service) GetWorkoutsByDate(ctx context.Context, date time.Time, trainerID int) ([]models.WorkoutResponse, error) {
-	workouts, err := s.repos.GetWorkoutsByDate(ctx, date, trainerID)
+func (s *Service) GetWorkoutsByDate(ctx context.Context, actor Actor, date time.Time) ([]models.WorkoutResponse, error) {
+	workouts, err := s.repos.GetWorkoutsByDate(ctx, date, actor.trainerScope())
 	if err != nil {
 		return nil, err
 	}
 	return workouts, nil
 }
 
-func (s *Service) GetWorkoutsByInterval(ctx context.Context, from, to time.Time, trainerID int) ([]models.WorkoutResponse, error) {
-	workouts, err := s.repos.GetWorkoutsByInterval(ctx, from, to, trainerID)
+func (s *Service) GetWorkoutsByInterval(ctx context.Context, actor Actor, from, to time.Time) ([]models.WorkoutResponse, error) {
+	workouts, err := s.repos.GetWorkoutsByInterval(ctx, from, to, actor.trainerScope())
 	if err != nil {
 		return nil, err
 	}
 	return workouts, nil
 }
 
-func (s *Service) GetWorkoutByID(ctx context.Context, id int) (models.WorkoutResponse, error) {
+func (s *Service) GetWorkoutByID(ctx context.Context, actor Actor, id int) (models.WorkoutResponse, error) {
 	workout, err := s.repos.GetWorkoutByID(ctx, id)
 	if err != nil {
 		return models.WorkoutResponse{}, err
 	}
+	if scope := actor.trainerScope(); scope != 0 && workout.Trainer.ID != scope {
+		return models.WorkoutResponse{}, dbr.ErrNotFound
+	}
+
+	workout.StatusHistory, err = s.repos.GetWorkoutStatusHistory(ctx, id)
+	if err != nil {
+		return models.WorkoutResponse{}, err
+	}
+
+	workout.Reschedules, err = s.repos.GetWorkoutReschedules(ctx, id)
+	if err != nil {
+		return models.WorkoutResponse{}, err
+	}
+
+	charge, err := s.repos.GetWorkoutCharge(ctx, id)
+	switch {
+	case err == nil:
+		workout.Charge = &charge
+	case !errors.Is(err, dbr.ErrNotFound):
+		return models.WorkoutResponse{}, err
+	}
 	return workout, nil
 }
 
-func (s *Service) GetWorkouts(ctx context.Context, trainerID, clientID int) ([]models.WorkoutResponse, error) {
+func (s *Service) GetWorkouts(ctx context.Context, actor Actor, trainerID, clientID int) ([]models.WorkoutResponse, error) {
+	if scope := actor.trainerScope(); scope != 0 {
+		if trainerID != 0 && trainerID != scope {
+			return make([]models.WorkoutResponse, 0), nil
+		}
+		trainerID = scope
+	}
+
 	workouts, err := s.repos.GetWorkouts(ctx, trainerID, clientID)
 	if err != nil {
 		return nil, err
@@ -270,6 +490,13 @@ func (s *Service) GetWorkouts(ctx context.Context, trainerID, clientID int) ([]m
 }
 
 func (s *Service) CreateWorkoutType(ctx context.Context, workoutType models.WorkoutType) error {
+	if workoutType.Duration <= 0 {
+		workoutType.Duration = defaultWorkoutDuration
+	}
+	if err := s.checkResource(ctx, workoutType.ResourceID); err != nil {
+		return err
+	}
+
 	err := s.repos.CreateWorkoutType(ctx, workoutType)
 	if err != nil {
 		return err
@@ -278,6 +505,17 @@ func (s *Service) CreateWorkoutType(ctx context.Context, workoutType models.Work
 }
 
 func (s *Service) UpdateWorkoutType(ctx context.Context, workoutType models.WorkoutType) error {
+	if workoutType.Duration <= 0 {
+		current, err := s.repos.GetWorkoutTypeByID(ctx, workoutType.ID)
+		if err != nil {
+			return err
+		}
+		workoutType.Duration = current.Duration
+	}
+	if err := s.checkResource(ctx, workoutType.ResourceID); err != nil {
+		return err
+	}
+
 	err := s.repos.UpdateWorkoutType(ctx, workoutType)
 	if err != nil {
 		return err
@@ -301,10 +539,85 @@ func (s *Service) GetWorkoutTypes(ctx context.Context) ([]models.WorkoutType, er
 	return workoutTypes, nil
 }
 
-func (s *Service) ChangeStatusWorkout(ctx context.Context, id int, status string) error {
-	err := s.repos.ChangeStatusWorkout(ctx, id, status)
+func (s *Service) ChangeStatusWorkout(ctx context.Context, actor Actor, req models.StatusChangeRequest) error {
+	id, status := req.WorkoutID, req.Status
+	if !status.Valid() {
+		return ErrInvalidStatus
+	}
+
+	workout, err := s.repos.GetWorkoutByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !workout.Status.CanTransitionTo(status) {
+		return ErrIllegalTransition
+	}
+	if err := s.checkPeriodOpen(ctx, workout.Date); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	charge, err := s.workoutCharge(ctx, workout, req, now)
+	if err != nil {
+		return err
+	}
+	membershipID, err := s.membershipCredit(ctx, workout, status)
+	if err != nil {
+		return err
+	}
+
+	err = s.repos.ChangeStatusWorkout(ctx, models.WorkoutStatusChange{
+		WorkoutID:    id,
+		FromStatus:   workout.Status,
+		ToStatus:     status,
+		ActorID:      actor.ID,
+		ActorRole:    actor.Role,
+		ChangedAt:    now,
+		Charge:       charge,
+		MembershipID: membershipID,
+		Ledger:       workoutLedgerEntry(workout, status, charge, membershipID),
+	})
 	if err != nil {
+		if errors.Is(err, repository.ErrStatusChanged) {
+			return ErrIllegalTransition
+		}
+		if errors.Is(err, repository.ErrNoCredit) {
+			return ErrNoCredit
+		}
 		return err
 	}
+
+	if status == models.StatusCanceled {
+		if freed, err := s.repos.GetWorkoutRequestByID(ctx, id); err == nil {
+			s.promoteToSlot(ctx, freed.TrainerID, freed.Date, freed.EndsAt)
+		}
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+// dummyPasswordHash is compared against when the login does not exist.
+var dummyPasswordHash, _ = hashPassword("dummy-password")
+
+func hashPassword(password string) (string, error) {
+	if password == "" {
+		return "", ErrEmptyPwd
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", ErrHashPwd
+	}
+	return string(hash), nil
+}
+
+func isPasswordHash(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
+
+func checkPassword(stored, password string) bool {
+	if isPasswordHash(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+}
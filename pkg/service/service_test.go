@@ -0,0 +1,25 @@
+package service
+
+import "testing"
+
+func TestTrainerScope(t *testing.T) {
+	tests := []struct {
+		role string
+		want int
+	}{
+		{RoleSudo, 0},
+		{RoleAdmin, 0},
+		{RoleTrainer, 7},
+		{RoleClient, noScope},
+		{"", noScope},
+		{"unknown", noScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			if got := (Actor{ID: 7, Role: tt.role}).trainerScope(); got != tt.want {
+				t.Errorf("trainerScope() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/gocraft/dbr/v2"
+)
+
+var (
+	ErrInvalidRefresh = errors.New("invalid refresh token")
+	ErrSessionRevoked = errors.New("session is revoked")
+)
+
+func (s *Service) RefreshTokens(ctx context.Context, refreshToken string) (models.Tokens, error) {
+	oldHash := hashToken(refreshToken)
+
+	session, err := s.repos.GetSessionByRefreshToken(ctx, oldHash)
+	if err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return models.Tokens{}, ErrInvalidRefresh
+		}
+		return models.Tokens{}, err
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return models.Tokens{}, ErrInvalidRefresh
+	}
+	if err = s.checkUserExists(ctx, session.UserID, session.Role); err != nil {
+		return models.Tokens{}, err
+	}
+
+	newRefreshToken, err := newRandomToken()
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	ok, err := s.repos.RotateSessionToken(ctx, session.ID, oldHash, hashToken(newRefreshToken), time.Now().Add(s.cfg.RefreshTokenTTL))
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	if !ok {
+		return models.Tokens{}, ErrInvalidRefresh
+	}
+
+	accessToken, err := s.signToken(session.UserID, session.Role, session.ID, s.cfg.TokenTTL)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	return models.Tokens{AccessToken: accessToken, RefreshToken: newRefreshToken}, nil
+}
+
+func (s *Service) Logout(ctx context.Context, refreshToken string) error {
+	return s.repos.RevokeSession(ctx, hashToken(refreshToken))
+}
+
+// Authenticate parses the access token and makes sure its session is still active
+// and its user still exists.
+func (s *Service) Authenticate(ctx context.Context, tokenString string) (int, string, error) {
+	claims, err := s.ParseToken(tokenString)
+	if err != nil {
+		return 0, "", err
+	}
+	if claims.Role == rolePreAuth {
+		return 0, "", ErrParseJWT
+	}
+
+	session, err := s.repos.GetSessionByID(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return 0, "", ErrSessionRevoked
+		}
+		return 0, "", err
+	}
+	if session.RevokedAt != nil || session.UserID != claims.UserID {
+		return 0, "", ErrSessionRevoked
+	}
+	if err = s.checkUserExists(ctx, claims.UserID, claims.Role); err != nil {
+		return 0, "", err
+	}
+
+	return claims.UserID, claims.Role, nil
+}
+
+func (s *Service) startSession(ctx context.Context, userID int, role string) (models.Tokens, error) {
+	refreshToken, err := newRandomToken()
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	sessionID, err := s.repos.CreateSession(ctx, models.Session{
+		UserID:           userID,
+		Role:             role,
+		RefreshTokenHash: hashToken(refreshToken),
+		ExpiresAt:        time.Now().Add(s.cfg.RefreshTokenTTL),
+	})
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	accessToken, err := s.signToken(userID, role, sessionID, s.cfg.TokenTTL)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	return models.Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func (s *Service) checkUserExists(ctx context.Context, userID int, role string) error {
+	var err error
+	switch role {
+	case RoleAdmin, RoleSudo:
+		_, err = s.repos.GetAdminByID(ctx, userID)
+	case RoleTrainer:
+		_, err = s.repos.GetTrainerByID(ctx, userID)
+	case RoleClient:
+		_, err = s.repos.GetClientByID(ctx, userID, 0)
+	default:
+		return ErrSessionRevoked
+	}
+
+	if errors.Is(err, dbr.ErrNotFound) {
+		return ErrSessionRevoked
+	}
+	return err
+}
+
+func newRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+)
+
+var (
+	ErrTOTPEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrTOTPNotEnrolled = errors.New("two-factor enrollment was not started")
+	ErrInvalidTOTP     = errors.New("invalid two-factor code")
+)
+
+const (
+	totpIssuer = "Fitness"
+	totpPeriod = 30
+	totpDigits = 6
+	// totpSkew is the number of periods accepted before and after the current one.
+	totpSkew = 1
+
+	mfaTokenTTL        = 5 * time.Minute
+	recoveryCodesCount = 10
+)
+
+// rolePreAuth marks tokens that only allow finishing the two-step sign-in.
+const rolePreAuth = "mfa"
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func (s *Service) EnrollTOTP(ctx context.Context, adminID int) (models.TOTPEnrollment, error) {
+	admin, err := s.repos.GetAdminByID(ctx, adminID)
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+	if admin.TOTPEnabled {
+		return models.TOTPEnrollment{}, ErrTOTPEnabled
+	}
+
+	secretBytes := make([]byte, 20)
+	if _, err = rand.Read(secretBytes); err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+	secret := totpEncoding.EncodeToString(secretBytes)
+
+	if err = s.repos.SetAdminTOTPSecret(ctx, adminID, secret); err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+	return models.TOTPEnrollment{Secret: secret, URI: totpURI(admin.Login, secret)}, nil
+}
+
+// ConfirmTOTP enables the second factor once the admin proves the authenticator
+// app is set up, and returns fresh recovery codes.
+func (s *Service) ConfirmTOTP(ctx context.Context, adminID int, code string) (models.RecoveryCodes, error) {
+	admin, err := s.repos.GetAdminByID(ctx, adminID)
+	if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+	if admin.TOTPEnabled {
+		return models.RecoveryCodes{}, ErrTOTPEnabled
+	}
+	if admin.TOTPSecret == nil {
+		return models.RecoveryCodes{}, ErrTOTPNotEnrolled
+	}
+	ok, err := s.useTOTP(ctx, admin.ID, *admin.TOTPSecret, code)
+	if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+	if !ok {
+		return models.RecoveryCodes{}, ErrInvalidTOTP
+	}
+
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, 5)
+		if _, err = rand.Read(b); err != nil {
+			return models.RecoveryCodes{}, err
+		}
+		code := hex.EncodeToString(b)
+		code = code[:5] + "-" + code[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(code))
+	}
+
+	if err = s.repos.EnableAdminTOTP(ctx, adminID, hashes); err != nil {
+		return models.RecoveryCodes{}, err
+	}
+	return models.RecoveryCodes{Codes: codes}, nil
+}
+
+func (s *Service) DisableTOTP(ctx context.Context, adminID int, code string) error {
+	admin, err := s.repos.GetAdminByID(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if !admin.TOTPEnabled {
+		return ErrTOTPNotEnabled
+	}
+
+	ok, err := s.checkSecondFactor(ctx, admin, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTOTP
+	}
+	return s.repos.DisableAdminTOTP(ctx, adminID)
+}
+
+// CompleteAdminSignIn exchanges the pre-auth token from GenerateTokenForAdmin
+// and a TOTP or recovery code for a regular session.
+func (s *Service) CompleteAdminSignIn(ctx context.Context, mfaToken, code, ip string) (models.Tokens, error) {
+	claims, err := s.ParseToken(mfaToken)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	if claims.Role != rolePreAuth {
+		return models.Tokens{}, ErrParseJWT
+	}
+
+	keys := []string{fmt.Sprintf("admin-mfa:%d", claims.UserID), ipAttemptKey(ip)}
+	if err = s.checkLockout(ctx, keys...); err != nil {
+		return models.Tokens{}, err
+	}
+
+	admin, err := s.repos.GetAdminByID(ctx, claims.UserID)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	if !admin.TOTPEnabled {
+		return models.Tokens{}, ErrTOTPNotEnabled
+	}
+
+	ok, err := s.checkSecondFactor(ctx, admin, code)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	if !ok {
+		return models.Tokens{}, s.failSignIn(ctx, keys...)
+	}
+	if err = s.repos.ClearLoginAttempts(ctx, keys[0]); err != nil {
+		return models.Tokens{}, err
+	}
+
+	return s.startSession(ctx, admin.ID, adminRole(admin))
+}
+
+func (s *Service) checkSecondFactor(ctx context.Context, admin models.Admin, code string) (bool, error) {
+	if admin.TOTPSecret != nil {
+		ok, err := s.useTOTP(ctx, admin.ID, *admin.TOTPSecret, code)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return s.repos.UseRecoveryCode(ctx, admin.ID, hashToken(strings.ToLower(strings.TrimSpace(code))))
+}
+
+// useTOTP checks the code and spends its time-step, so that a code seen by
+// someone else cannot be replayed while it is still valid.
+func (s *Service) useTOTP(ctx context.Context, adminID int, secret, code string) (bool, error) {
+	step, ok := verifyTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return s.repos.UseTOTPStep(ctx, adminID, step)
+}
+
+func totpURI(account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", totpIssuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(totpDigits))
+	v.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(totpIssuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// verifyTOTP returns the time-step the code belongs to.
+func verifyTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	step := now.Unix() / totpPeriod
+	for i := -totpSkew; i <= totpSkew; i++ {
+		expected := totpCode(key, uint64(step+int64(i)))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// totpCode implements RFC 6238 on top of the RFC 4226 HOTP algorithm.
+func totpCode(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%modulus)
+}
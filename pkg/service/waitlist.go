@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hymiside/fitness-api/pkg/models"
+	"github.com/Hymiside/fitness-api/pkg/repository"
+	"github.com/gocraft/dbr/v2"
+)
+
+var (
+	ErrWaitlistTarget = errors.New("waitlist entry needs either a class or a trainer, a workout type and a date")
+	ErrPlaceAvailable = errors.New("there is a free place, book it instead")
+	ErrAlreadyWaiting = errors.New("client is already on the waitlist")
+	ErrNotWaiting     = errors.New("waitlist entry is not waiting")
+)
+
+// JoinWaitlist puts the client in line for a full class or a taken trainer slot.
+func (s *Service) JoinWaitlist(ctx context.Context, actor Actor, entry models.WaitlistEntry) error {
+	if _, err := s.repos.GetClientByID(ctx, entry.ClientID, 0); err != nil {
+		if errors.Is(err, dbr.ErrNotFound) {
+			return ErrClientNotFound
+		}
+		return err
+	}
+
+	switch {
+	case entry.ClassID != nil && entry.TrainerID == nil && entry.WorkoutTypeID == nil && entry.Date == nil:
+		class, err := s.repos.GetClassSessionByID(ctx, *entry.ClassID)
+		if err != nil {
+			return err
+		}
+		if class.Status != models.StatusPending {
+			return ErrClassClosed
+		}
+		if class.Booked < class.Capacity {
+			return ErrPlaceAvailable
+		}
+	case entry.ClassID == nil && entry.TrainerID != nil && entry.WorkoutTypeID != nil && entry.Date != nil:
+		workout := models.WorkoutRequest{
+			ClientID:      entry.ClientID,
+			TrainerID:     *entry.TrainerID,
+			WorkoutTypeID: *entry.WorkoutTypeID,
+			Date:          *entry.Date,
+		}
+		if err := s.validateWorkout(ctx, workout); err != nil {
+			return err
+		}
+
+		// only the trainer matters, the client's own bookings do not free the slot
+		workout.ClientID = 0
+		err := s.placeWorkout(ctx, &workout)
+		if err == nil {
+			return ErrPlaceAvailable
+		}
+		if !errors.Is(err, ErrWorkoutConflict) {
+			return err
+		}
+	default:
+		return ErrWaitlistTarget
+	}
+
+	entry.AdminID = actor.ID
+	err := s.repos.CreateWaitlistEntry(ctx, entry)
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return ErrAlreadyWaiting
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) GetClassWaitlist(ctx context.Context, classID int) ([]models.WaitlistEntry, error) {
+	if err := s.repos.ExpireWaitlist(ctx, time.Now()); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repos.GetClassWaitlist(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetSlotWaitlist lists the clients waiting for a workout with the trainer
+// that would take place at the given time.
+func (s *Service) GetSlotWaitlist(ctx context.Context, trainerID int, date time.Time) ([]models.WaitlistEntry, error) {
+	if err := s.repos.ExpireWaitlist(ctx, time.Now()); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repos.GetSlotWaitlist(ctx, trainerID, date, date.Add(time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *Service) LeaveWaitlist(ctx context.Context, id int) error {
+	err := s.repos.ChangeWaitlistStatus(ctx, id, models.WaitlistWaiting, models.WaitlistLeft)
+	if err != nil {
+		if errors.Is(err, repository.ErrStatusChanged) {
+			return ErrNotWaiting
+		}
+		return err
+	}
+	return nil
+}
+
+// promoteToSlot books the first client waiting for a workout with the
+// trainer that overlaps the freed time from date to endsAt. Clients who
+// cannot be booked keep their place in line. Promotion never fails the
+// change that freed the slot; what went wrong is kept on the entry.
+func (s *Service) promoteToSlot(ctx context.Context, trainerID int, date, endsAt time.Time) {
+	if err := s.repos.ExpireWaitlist(ctx, time.Now()); err != nil {
+		return
+	}
+	entries, err := s.repos.GetSlotWaitlist(ctx, trainerID, date, endsAt)
+	if err != nil {
+		return
+	}
+
+	for i := range entries {
+		err := s.promote(ctx, entries[i], func() error {
+			return s.CreateWorkout(ctx, models.WorkoutRequest{
+				ClientID:      entries[i].ClientID,
+				TrainerID:     *entries[i].TrainerID,
+				WorkoutTypeID: *entries[i].WorkoutTypeID,
+				AdminID:       entries[i].AdminID,
+				Date:          *entries[i].Date,
+			})
+		})
+		if err == nil {
+			return
+		}
+	}
+}
+
+// promoteToClass books the first client waiting for a place in the class.
+func (s *Service) promoteToClass(ctx context.Context, classID int) {
+	if err := s.repos.ExpireWaitlist(ctx, time.Now()); err != nil {
+		return
+	}
+	entries, err := s.repos.GetClassWaitlist(ctx, classID)
+	if err != nil {
+		return
+	}
+
+	for i := range entries {
+		err := s.promote(ctx, entries[i], func() error {
+			return s.BookClass(ctx, models.ClassBooking{ClassID: classID, ClientID: entries[i].ClientID})
+		})
+		// the place was taken by someone else, there is nothing to promote to
+		if err == nil || errors.Is(err, ErrClassFull) || errors.Is(err, ErrClassClosed) {
+			return
+		}
+	}
+}
+
+// promote claims the entry so that concurrent promotions skip it and runs
+// book. When booking fails the entry goes back to the line at its old place
+// with the reason, which staff see in the waitlist.
+func (s *Service) promote(ctx context.Context, entry models.WaitlistEntry, book func() error) error {
+	err := s.repos.ChangeWaitlistStatus(ctx, entry.ID, models.WaitlistWaiting, models.WaitlistPromoted)
+	if err != nil {
+		return err
+	}
+
+	if err := book(); err != nil {
+		// a failure to return the entry leaves it promoted, staff see it
+		// is no longer waiting while the client has no booking
+		_ = s.repos.ReturnWaitlistEntry(ctx, entry.ID, err.Error())
+		return err
+	}
+
+	s.notifyPromotion(ctx, entry)
+	return nil
+}
+
+// notifyPromotion tells the client that a place opened up and they are
+// booked. The booking stands even when the message cannot be delivered.
+func (s *Service) notifyPromotion(ctx context.Context, entry models.WaitlistEntry) {
+	if s.sender == nil {
+		return
+	}
+	client, err := s.repos.GetClientByID(ctx, entry.ClientID, 0)
+	if err != nil {
+		return
+	}
+
+	var to string
+	switch {
+	case client.PhoneNumber != nil:
+		to = *client.PhoneNumber
+	case client.Email != nil:
+		to = *client.Email
+	default:
+		return
+	}
+
+	message := "A place opened up on the waitlist and you have been booked."
+	if entry.Date != nil {
+		message = fmt.Sprintf("A place opened up on the waitlist and you have been booked for %s.", entry.Date.Format("02.01.2006 15:04"))
+	}
+	_ = s.sender.Send(ctx, to, message)
+}